@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: base.proto
+
+package basepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BaseService_GetAll_FullMethodName = "/microzoo.BaseService/GetAll"
+	BaseService_Create_FullMethodName = "/microzoo.BaseService/Create"
+)
+
+// BaseServiceClient is the client API for BaseService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BaseServiceClient interface {
+	GetAll(ctx context.Context, in *GetAllRequest, opts ...grpc.CallOption) (*GetAllResponse, error)
+	Create(ctx context.Context, in *BaseDto, opts ...grpc.CallOption) (*BaseDto, error)
+}
+
+type baseServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBaseServiceClient(cc grpc.ClientConnInterface) BaseServiceClient {
+	return &baseServiceClient{cc}
+}
+
+func (c *baseServiceClient) GetAll(ctx context.Context, in *GetAllRequest, opts ...grpc.CallOption) (*GetAllResponse, error) {
+	out := new(GetAllResponse)
+	err := c.cc.Invoke(ctx, BaseService_GetAll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *baseServiceClient) Create(ctx context.Context, in *BaseDto, opts ...grpc.CallOption) (*BaseDto, error) {
+	out := new(BaseDto)
+	err := c.cc.Invoke(ctx, BaseService_Create_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BaseServiceServer is the server API for BaseService service.
+// All implementations must embed UnimplementedBaseServiceServer
+// for forward compatibility
+type BaseServiceServer interface {
+	GetAll(context.Context, *GetAllRequest) (*GetAllResponse, error)
+	Create(context.Context, *BaseDto) (*BaseDto, error)
+	mustEmbedUnimplementedBaseServiceServer()
+}
+
+// UnimplementedBaseServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBaseServiceServer struct {
+}
+
+func (UnimplementedBaseServiceServer) GetAll(context.Context, *GetAllRequest) (*GetAllResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
+}
+func (UnimplementedBaseServiceServer) Create(context.Context, *BaseDto) (*BaseDto, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedBaseServiceServer) mustEmbedUnimplementedBaseServiceServer() {}
+
+// UnsafeBaseServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BaseServiceServer will
+// result in compilation errors.
+type UnsafeBaseServiceServer interface {
+	mustEmbedUnimplementedBaseServiceServer()
+}
+
+func RegisterBaseServiceServer(s grpc.ServiceRegistrar, srv BaseServiceServer) {
+	s.RegisterService(&BaseService_ServiceDesc, srv)
+}
+
+func _BaseService_GetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BaseServiceServer).GetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BaseService_GetAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BaseServiceServer).GetAll(ctx, req.(*GetAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BaseService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BaseDto)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BaseServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BaseService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BaseServiceServer).Create(ctx, req.(*BaseDto))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BaseService_ServiceDesc is the grpc.ServiceDesc for BaseService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BaseService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "microzoo.BaseService",
+	HandlerType: (*BaseServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAll",
+			Handler:    _BaseService_GetAll_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _BaseService_Create_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "base.proto",
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errAccessDenied markiert Entitäten, die einem anderen Client gehören
+var errAccessDenied = errors.New("access denied")
+
+// callerIdentity ist ein Hash des API-Keys des Aufrufers, gegen den das
+// owner-Feld einer Entität geprüft wird. Gespeichert und ausgeliefert wird
+// nur der Hash, damit der Key nicht über das owner-Feld lesbar wird.
+func callerIdentity(c *gin.Context) string {
+	return apiKeyIdentity(c.GetHeader("X-API-Key"))
+}
+
+// apiKeyIdentity hasht einen API-Key; ohne Key gibt es keine Identität
+func apiKeyIdentity(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+// canAccess prüft die Zugriffsregel: Entitäten ohne Owner sind für alle
+// sichtbar, alle anderen nur für den Client mit passendem API-Key.
+func canAccess(identity string, dto BaseDto) bool {
+	if !config.AccessControl || dto.Owner == "" {
+		return true
+	}
+	return dto.Owner == identity
+}
+
+// filterAccessible entfernt alle Entitäten, die der Aufrufer nicht sehen darf
+func filterAccessible(identity string, dtos []BaseDto) []BaseDto {
+	if !config.AccessControl {
+		return dtos
+	}
+	visible := make([]BaseDto, 0, len(dtos))
+	for _, dto := range dtos {
+		if canAccess(identity, dto) {
+			visible = append(visible, dto)
+		}
+	}
+	return visible
+}
+
+// canOverwrite prüft vor dem Speichern, ob eine vorhandene Entität mit
+// derselben ID dem Aufrufer gehört. Ohne Datenbank gibt es nichts zu prüfen.
+func canOverwrite(ctx context.Context, identity string, id string) (bool, error) {
+	if !config.AccessControl || !isDBActive() {
+		return true, nil
+	}
+	existing, found, err := getOneFromDB(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return !found || canAccess(identity, existing), nil
+}
+
+// assignOwner setzt beim Schreiben den Aufrufer als Owner. Ein mitgeschickter
+// Owner wird ignoriert, damit kein Client Entitäten für andere anlegen kann.
+func assignOwner(identity string, dto *BaseDto) {
+	if config.AccessControl {
+		dto.Owner = identity
+	}
+}
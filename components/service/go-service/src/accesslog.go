@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// accessLogEntry ist eine Zeile im Access-Log
+type accessLogEntry struct {
+	Time      string  `json:"time"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	ClientIP  string  `json:"clientIp"`
+	Bytes     int     `json:"bytes"`
+	RequestID string  `json:"requestId,omitempty"`
+}
+
+// accessLogMiddleware schreibt pro Request eine JSON-Zeile in eine Datei, die
+// ab AccessLogMaxSizeMB rotiert wird
+func accessLogMiddleware() gin.HandlerFunc {
+	writer := &lumberjack.Logger{
+		Filename:   config.AccessLogFile,
+		MaxSize:    config.AccessLogMaxSizeMB,
+		MaxBackups: config.AccessLogMaxBackups,
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		line, err := json.Marshal(accessLogEntry{
+			Time:      start.UTC().Format(time.RFC3339Nano),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			ClientIP:  c.ClientIP(),
+			Bytes:     c.Writer.Size(),
+			RequestID: c.GetString("requestId"),
+		})
+		if err == nil {
+			_, err = writer.Write(append(line, '\n'))
+		}
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Konnte Access-Log nicht schreiben", "error", err)
+		}
+	}
+}
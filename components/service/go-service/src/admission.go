@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	admissionModeFifo = "fifo"
+	admissionModeFair = "fair"
+
+	// Höchstzahl unterschiedlicher client-Labels, weitere Clients landen in "other"
+	maxClientLabels = 100
+)
+
+var (
+	clientLabelsMu sync.Mutex
+	clientLabels   = map[string]bool{}
+)
+
+var admissionWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "microzoo_admission_wait_seconds",
+	Help:    "Wartezeit eines Requests auf einen freien Bearbeitungsslot pro Client (Hash)",
+	Buckets: prometheus.DefBuckets,
+}, []string{"client"})
+
+var admissionServiceSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "microzoo_admission_service_seconds",
+	Help:    "Bearbeitungszeit eines zugelassenen Requests ohne Wartezeit pro Client (Hash)",
+	Buckets: prometheus.DefBuckets,
+}, []string{"client"})
+
+// fairScheduler vergibt eine begrenzte Anzahl von Bearbeitungsslots. Wartende
+// Requests werden pro Client in eigenen Queues gehalten und reihum (round-robin)
+// zugelassen, damit ein einzelner Client die anderen nicht aushungern kann.
+type fairScheduler struct {
+	mu     sync.Mutex
+	free   int
+	queues map[string][]chan struct{}
+	order  []string
+	next   int
+}
+
+func newFairScheduler(slots int) *fairScheduler {
+	return &fairScheduler{free: slots, queues: map[string][]chan struct{}{}}
+}
+
+// acquire wartet, bis der Request des Clients einen Slot erhält oder der
+// Kontext abgebrochen wird.
+func (s *fairScheduler) acquire(ctx context.Context, client string) error {
+	s.mu.Lock()
+	if s.free > 0 && len(s.order) == 0 {
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	admitted := make(chan struct{})
+	if len(s.queues[client]) == 0 {
+		s.order = append(s.order, client)
+	}
+	s.queues[client] = append(s.queues[client], admitted)
+	s.mu.Unlock()
+
+	select {
+	case <-admitted:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := s.remove(client, admitted)
+		s.mu.Unlock()
+		if !removed {
+			// Der Slot wurde parallel zum Abbruch vergeben und muss zurückgegeben werden
+			s.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release gibt einen Slot frei und lässt den nächsten Client in der Runde zu
+func (s *fairScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.order) == 0 {
+		s.free++
+		return
+	}
+	if s.next >= len(s.order) {
+		s.next = 0
+	}
+	client := s.order[s.next]
+	queue := s.queues[client]
+	admitted := queue[0]
+	if len(queue) == 1 {
+		delete(s.queues, client)
+		s.order = append(s.order[:s.next], s.order[s.next+1:]...)
+	} else {
+		s.queues[client] = queue[1:]
+		s.next++
+	}
+	close(admitted)
+}
+
+// remove entfernt einen wartenden Request aus der Queue des Clients. Der
+// Aufrufer muss s.mu halten.
+func (s *fairScheduler) remove(client string, admitted chan struct{}) bool {
+	queue := s.queues[client]
+	for i, candidate := range queue {
+		if candidate != admitted {
+			continue
+		}
+		queue = append(queue[:i], queue[i+1:]...)
+		if len(queue) > 0 {
+			s.queues[client] = queue
+			return true
+		}
+		delete(s.queues, client)
+		for j, name := range s.order {
+			if name == client {
+				s.order = append(s.order[:j], s.order[j+1:]...)
+				if j < s.next {
+					s.next--
+				}
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// clientKey identifiziert den Client über seinen API-Key oder seine IP-Adresse
+func clientKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	return c.ClientIP()
+}
+
+// clientLabel bildet den Client auf ein Label der Admission-Metriken ab. Es
+// enthält nur einen kurzen Hash, damit API-Keys nicht unter /metrics
+// erscheinen, und die Zahl der Labels bleibt auf maxClientLabels begrenzt.
+func clientLabel(client string) string {
+	sum := sha256.Sum256([]byte(client))
+	label := hex.EncodeToString(sum[:4])
+
+	clientLabelsMu.Lock()
+	defer clientLabelsMu.Unlock()
+	if !clientLabels[label] {
+		if len(clientLabels) >= maxClientLabels {
+			return "other"
+		}
+		clientLabels[label] = true
+	}
+	return label
+}
+
+// admissionMiddleware begrenzt die gleichzeitig bearbeiteten Requests. Im
+// Modus fifo teilen sich alle Clients eine Queue, im Modus fair erhält jeder
+// Client eine eigene. Warte- und Bearbeitungszeit werden getrennt erfasst und
+// mit ServerTiming im Server-Timing-Header zurückgegeben.
+func admissionMiddleware(scheduler *fairScheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := clientKey(c)
+		queue := ""
+		if config.AdmissionMode == admissionModeFair {
+			queue = client
+		}
+
+		start := time.Now()
+		if err := scheduler.acquire(c.Request.Context(), queue); err != nil {
+			abortWithError(c, http.StatusServiceUnavailable, "request cancelled while waiting for admission")
+			return
+		}
+		waited := time.Since(start)
+		label := clientLabel(client)
+		admissionWaitSeconds.WithLabelValues(label).Observe(waited.Seconds())
+		defer scheduler.release()
+
+		started := time.Now()
+		if config.ServerTiming {
+			c.Writer = &serverTimingWriter{ResponseWriter: c.Writer, queue: waited, started: started}
+		}
+		c.Next()
+		admissionServiceSeconds.WithLabelValues(label).Observe(time.Since(started).Seconds())
+	}
+}
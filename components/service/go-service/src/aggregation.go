@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var aggregationResponseBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "microzoo_aggregation_response_bytes",
+	Help:    "Größe aggregierter Upstream-Antworten vor (raw) und nach (compressed) der Kompression",
+	Buckets: prometheus.ExponentialBuckets(256, 4, 10),
+}, []string{"stage"})
+
+const (
+	aggregationStrategyConcat     = "concat"
+	aggregationStrategyFirst      = "first"
+	aggregationStrategyMergeDedup = "merge-dedup"
+)
+
+// parseAggregationStrategy liest, wie die Ergebnisse mehrerer Upstreams
+// zusammengeführt werden
+func parseAggregationStrategy(strategyStr string) string {
+	switch strategy := strings.ToLower(strings.TrimSpace(strategyStr)); strategy {
+	case "", aggregationStrategyConcat:
+		return aggregationStrategyConcat
+	case aggregationStrategyFirst, aggregationStrategyMergeDedup:
+		return strategy
+	default:
+		slog.Warn("Unbekannte AggregationStrategy, verwende Default", "aggregationStrategy", strategyStr, "default", aggregationStrategyConcat)
+		return aggregationStrategyConcat
+	}
+}
+
+// aggregateUpstreamResults führt die Ergebnisse der Upstreams in der
+// Reihenfolge ihres Eintreffens zusammen: concat hängt alle aneinander, first
+// liefert nur das Ergebnis des schnellsten Upstreams und merge-dedup behält
+// von Entitäten mit gleicher ID nur die zuerst eingetroffene.
+func aggregateUpstreamResults(results [][]BaseDto) []BaseDto {
+	if len(results) == 0 {
+		return nil
+	}
+	if config.AggregationStrategy == aggregationStrategyFirst {
+		return results[0]
+	}
+
+	var dtos []BaseDto
+	seen := map[string]bool{}
+	for _, result := range results {
+		for _, dto := range result {
+			if config.AggregationStrategy == aggregationStrategyMergeDedup {
+				if seen[dto.ID] {
+					continue
+				}
+				seen[dto.ID] = true
+			}
+			dtos = append(dtos, dto)
+		}
+	}
+	return dtos
+}
+
+func acceptsGzip(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept-Encoding"), "gzip")
+}
+
+// writeAggregatedResponse liefert das Ergebnis einer Upstream-Aggregation aus.
+// Ist CompressAggregation aktiv und akzeptiert der Client gzip, wird die
+// Antwort bereits hier komprimiert. Der gesetzte Content-Encoding-Header
+// verhindert, dass die Antwort ein zweites Mal komprimiert wird. XML-Antworten
+// werden nicht vorab komprimiert, ebenso wenig signierte Antworten, da die
+// Signatur über den unkomprimierten Body gebildet wird.
+func writeAggregatedResponse(c *gin.Context, dtos []BaseDto) {
+	if !config.CompressAggregation || !acceptsGzip(c) || wantsXML(c) || isSigningEnabled() {
+		respondNegotiated(c, http.StatusOK, dtos)
+		return
+	}
+
+	simulateSerialization(len(dtos))
+	raw, err := json.Marshal(dtos)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(raw); err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Konnte aggregierte Antwort nicht komprimieren", "error", err)
+		c.Data(http.StatusOK, "application/json; charset=utf-8", raw)
+		return
+	}
+
+	aggregationResponseBytes.WithLabelValues("raw").Observe(float64(len(raw)))
+	aggregationResponseBytes.WithLabelValues("compressed").Observe(float64(compressed.Len()))
+
+	c.Header("Content-Encoding", "gzip")
+	c.Header("Vary", "Accept-Encoding")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", compressed.Bytes())
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyMiddleware verlangt einen der konfigurierten API-Keys im Header
+// X-API-Key
+func apiKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validAPIKey(c.GetHeader("X-API-Key")) {
+			abortWithError(c, http.StatusUnauthorized, "valid X-API-Key required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// validAPIKey prüft, ob presented einer der konfigurierten API-Keys ist. Es
+// werden immer alle Keys in konstanter Zeit verglichen, damit die Antwortzeit
+// nichts über die Keys verrät.
+func validAPIKey(presented string) bool {
+	valid := 0
+	for _, key := range config.APIKeys {
+		valid |= subtle.ConstantTimeCompare([]byte(presented), []byte(key))
+	}
+	return presented != "" && valid == 1
+}
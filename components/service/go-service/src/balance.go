@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	upstreamModeFanout  = "fanout"
+	upstreamModeBalance = "balance"
+)
+
+// splitUpstreamWeights trennt die Gewichtungen von den Upstream-Services im
+// Format "http://svc-a:8080=3,http://svc-b:8080=1". Ohne Gewichtung gilt 1.
+func splitUpstreamWeights(entries []string) ([]string, map[string]int) {
+	services := []string{}
+	weights := map[string]int{}
+	for _, serviceStr := range entries {
+		url, weight := serviceStr, 1
+		if i := strings.LastIndex(serviceStr, "="); i >= 0 {
+			if parsed, err := strconv.Atoi(serviceStr[i+1:]); err == nil {
+				url, weight = serviceStr[:i], parsed
+			}
+		}
+		if weight <= 0 {
+			slog.Warn("Ungültige Gewichtung für Upstream, verwende 1", "weight", weight, "upstream", url)
+			weight = 1
+		}
+		services = append(services, url)
+		weights[url] = weight
+	}
+	return services, weights
+}
+
+// parseUpstreamMode liest, ob alle Upstreams abgefragt (fanout) oder je
+// Request einer gewählt wird (balance)
+func parseUpstreamMode(modeStr string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(modeStr)); mode {
+	case "", upstreamModeFanout:
+		return upstreamModeFanout
+	case upstreamModeBalance:
+		return mode
+	default:
+		slog.Warn("Unbekannter UpstreamMode, verwende Default", "upstreamMode", modeStr, "default", upstreamModeFanout)
+		return upstreamModeFanout
+	}
+}
+
+// weightedRoundRobin verteilt die Auswahl gemäß den Gewichtungen gleichmäßig
+// über die Zeit (Smooth Weighted Round-Robin wie in nginx)
+type weightedRoundRobin struct {
+	mu      sync.Mutex
+	current map[string]int
+}
+
+var upstreamBalancer = &weightedRoundRobin{current: map[string]int{}}
+
+func (b *weightedRoundRobin) next(services []string, weights map[string]int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	total, best := 0, ""
+	for _, service := range services {
+		weight := max(weights[service], 1)
+		total += weight
+		b.current[service] += weight
+		if best == "" || b.current[service] > b.current[best] {
+			best = service
+		}
+	}
+	b.current[best] -= total
+	return best
+}
+
+// configuredUpstreams liefert im Modus fanout alle Upstream-Services, im
+// Modus balance einen per gewichtetem Round-Robin gewählten
+func configuredUpstreams() []string {
+	if config.UpstreamMode != upstreamModeBalance || len(config.UpstreamServices) <= 1 {
+		return config.UpstreamServices
+	}
+	return []string{upstreamBalancer.next(config.UpstreamServices, config.UpstreamWeights)}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maximale Größe eines Schreibvorgangs, damit sich parallele Antworten die
+// Bandbreite gleichmäßig teilen
+const bandwidthChunkSize = 4096
+
+// tokenBucket wird mit rate Tokens pro Sekunde bis zu capacity aufgefüllt
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+var globalBandwidth *tokenBucket
+
+func newTokenBucket(rate, capacity float64) *tokenBucket {
+	return &tokenBucket{rate: rate, capacity: capacity, tokens: capacity, last: time.Now()}
+}
+
+// refill muss mit gehaltenem Lock aufgerufen werden
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// take entnimmt n Tokens und wartet, bis diese verfügbar sind. Die Reservierung
+// erfolgt sofort, damit wartende Writer in Ankunftsreihenfolge bedient werden.
+func (b *tokenBucket) take(n int) {
+	b.mu.Lock()
+	b.refill()
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	time.Sleep(wait)
+}
+
+// tryTake entnimmt ein Token, falls eines verfügbar ist, ohne zu warten
+func (b *tokenBucket) tryTake() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// full meldet, ob der Bucket wieder vollständig aufgefüllt ist
+func (b *tokenBucket) full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return b.tokens >= b.capacity
+}
+
+// nextTokenIn liefert die Wartezeit, bis wieder ein Token verfügbar ist
+func (b *tokenBucket) nextTokenIn() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// throttledResponseWriter schreibt den Body in Blöcken und bezieht für jeden
+// Block Tokens aus dem globalen Bucket
+type throttledResponseWriter struct {
+	gin.ResponseWriter
+	bucket *tokenBucket
+}
+
+func (w *throttledResponseWriter) Write(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		end := written + bandwidthChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		w.bucket.take(end - written)
+		n, err := w.ResponseWriter.Write(data[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		w.ResponseWriter.Flush()
+	}
+	return written, nil
+}
+
+func (w *throttledResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// bandwidthMiddleware begrenzt den gesamten ausgehenden Traffic des Prozesses
+// auf GlobalBandwidthBytesPerSec. Antworten im HTTP/1.0-Modus umgehen die
+// Begrenzung, da sie direkt auf die übernommene Verbindung geschrieben werden.
+func bandwidthMiddleware() gin.HandlerFunc {
+	// Der Bucket fasst höchstens eine Sekunde Kapazität
+	rate := float64(config.GlobalBandwidthBytesPerSec)
+	globalBandwidth = newTokenBucket(rate, rate)
+	return func(c *gin.Context) {
+		c.Writer = &throttledResponseWriter{ResponseWriter: c.Writer, bucket: globalBandwidth}
+		c.Next()
+	}
+}
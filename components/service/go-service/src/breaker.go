@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// circuitBreaker schützt einen Upstream: Nach BreakerFailureThreshold
+// aufeinanderfolgenden Fehlern wird er geöffnet und Aufrufe schlagen sofort
+// fehl. Nach BreakerCooldown lässt er im Zustand half-open einen Probeaufruf
+// durch, dessen Ergebnis ihn wieder schließt oder erneut öffnet.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// BreakerState ist der Zustand eines Circuit Breakers in /actuator/breakers
+type BreakerState struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+var (
+	breakersLock sync.Mutex
+	breakers     = map[string]*circuitBreaker{}
+)
+
+func breakerFor(serviceURL string) *circuitBreaker {
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+	breaker, ok := breakers[serviceURL]
+	if !ok {
+		breaker = &circuitBreaker{state: breakerClosed}
+		breakers[serviceURL] = breaker
+	}
+	return breaker
+}
+
+// allow prüft, ob ein Aufruf durchgelassen wird
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen && time.Since(b.openedAt) >= config.BreakerCooldown {
+		b.state = breakerHalfOpen
+	}
+	switch b.state {
+	case breakerOpen:
+		return false
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// record wertet das Ergebnis eines durchgelassenen Aufrufs aus
+func (b *circuitBreaker) record(ctx context.Context, serviceURL string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		if b.state != breakerClosed {
+			slog.InfoContext(ctx, "Circuit breaker closed", "upstream", serviceURL)
+		}
+		b.state, b.failures = breakerClosed, 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= config.BreakerFailureThreshold {
+		if b.state != breakerOpen {
+			slog.WarnContext(ctx, "Circuit Breaker geöffnet", "upstream", serviceURL, "failures", b.failures)
+		}
+		b.state, b.openedAt = breakerOpen, time.Now()
+	}
+}
+
+func (b *circuitBreaker) snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := BreakerState{State: b.state, Failures: b.failures}
+	if b.state != breakerClosed {
+		openedAt := b.openedAt
+		state.OpenedAt = &openedAt
+	}
+	return state
+}
+
+// throughBreaker führt call nur aus, wenn der Circuit Breaker des Upstreams
+// es erlaubt. Als Fehler zählen nur Verbindungsfehler und 5xx.
+func throughBreaker[T any](ctx context.Context, serviceURL string, call func() (T, error)) (T, error) {
+	if config.BreakerFailureThreshold <= 0 {
+		return call()
+	}
+	breaker := breakerFor(serviceURL)
+	if !breaker.allow() {
+		var zero T
+		return zero, fmt.Errorf("circuit breaker for %s is open", serviceURL)
+	}
+	result, err := call()
+	breaker.record(ctx, serviceURL, err != nil && isRetryable(err))
+	return result, err
+}
+
+// getBreakers liefert die Zustände aller Circuit Breaker je Upstream
+func getBreakers(c *gin.Context) {
+	breakersLock.Lock()
+	states := make(map[string]BreakerState, len(breakers))
+	for serviceURL, breaker := range breakers {
+		states[serviceURL] = breaker.snapshot()
+	}
+	breakersLock.Unlock()
+	c.JSON(http.StatusOK, states)
+}
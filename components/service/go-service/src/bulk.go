@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkItemResult ist das Ergebnis einer einzelnen Entität eines Bulk-Requests
+type BulkItemResult struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResult ist die Antwort von POST /api/base/bulk
+type BulkResult struct {
+	Saved     int              `json:"saved"`
+	FailedIDs []string         `json:"failedIds,omitempty"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// errInjectedBulkFailure markiert Entitäten, deren Fehlschlag gemäß
+// BulkFailureRate simuliert wurde
+var errInjectedBulkFailure = errors.New("injected failure")
+
+// createBulk speichert ein Array von Entitäten. Antwortet mit 201, wenn alle
+// gespeichert wurden, mit 207 bei teilweisem Erfolg und sonst mit dem Status
+// des ersten Fehlers. Die Antwort enthält den Status jeder Entität. Mit
+// Datenbank wird der Batch nur als Ganzes gespeichert, ein Fehler verwirft
+// alle Entitäten; gemäß BulkFailureRate simulierte Fehlschläge zählen dabei
+// nicht, sodass auch mit Datenbank 207 möglich ist.
+func createBulk(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "Entered POST /api/base/bulk")
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+
+	burnCPU(c.Request.Context())
+	defer runtime.KeepAlive(allocateChurn())
+
+	var dtos []BaseDto
+	if err := c.ShouldBindJSON(&dtos); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	for i := range dtos {
+		assignID(&dtos[i])
+		if err := validateBaseDto(dtos[i]); err != nil {
+			respondError(c, http.StatusBadRequest, fmt.Sprintf("item %d: %v", i, err))
+			return
+		}
+		assignOwner(callerIdentity(c), &dtos[i])
+	}
+
+	// Simulierte Fehlschläge und fremde Entitäten werden gar nicht erst gespeichert
+	errs := make([]error, len(dtos))
+	var pending []int
+	for i := range dtos {
+		if config.BulkFailureRate > 0 && nextFailureRand() < config.BulkFailureRate {
+			errs[i] = errInjectedBulkFailure
+			continue
+		}
+		allowed, err := canOverwrite(c.Request.Context(), callerIdentity(c), dtos[i].ID)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if !allowed {
+			errs[i] = errAccessDenied
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	source := "none"
+	switch upstreams := selectUpstreams(c); {
+	case isDBActive():
+		source = "repository"
+		// Simulierte Fehlschläge verwerfen den Batch nicht, die übrigen
+		// Entitäten werden gespeichert
+		if rollBackBatch(dtos, errs, errInjectedBulkFailure) {
+			break
+		}
+		slog.InfoContext(c.Request.Context(), "Saving entities in repository", "count", len(pending))
+		batch := make([]BaseDto, len(pending))
+		for j, i := range pending {
+			batch[j] = dtos[i]
+		}
+		saved, saveErrs := saveAllToDB(c.Request.Context(), batch)
+		for j, i := range pending {
+			dtos[i], errs[i] = saved[j], saveErrs[j]
+			if errs[i] == nil {
+				staleEntities.put(dtos[i])
+			}
+		}
+		listCache.invalidate()
+	case len(upstreams) > 0:
+		source = "upstream"
+		slog.InfoContext(c.Request.Context(), "Posting entities to upstream services", "count", len(pending), "upstreams", upstreams)
+		for _, i := range pending {
+			applyRequestTransform(&dtos[i])
+			for _, serviceURL := range upstreams {
+				echoed, err := withFailover(c.Request.Context(), serviceURL, func(serviceURL string) (BaseDto, error) {
+					return postToUpstream(c.Request.Context(), serviceURL, dtos[i])
+				})
+				if err != nil {
+					errs[i] = &upstreamItemError{err: err}
+					break
+				}
+				dtos[i] = echoed
+			}
+		}
+	}
+
+	result := BulkResult{Items: make([]BulkItemResult, len(dtos))}
+	for i, dto := range dtos {
+		if errs[i] == nil && kafkaWriter != nil {
+			if err := publishEntity(c.Request.Context(), dto); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht an Kafka senden",
+					"id", dto.ID, "topic", config.KafkaTopic, "failMode", config.KafkaFailMode, "error", err)
+				if config.KafkaFailMode == kafkaFailModeFatal {
+					errs[i] = err
+				}
+			}
+		}
+
+		item := BulkItemResult{ID: dto.ID, Status: http.StatusCreated}
+		if errs[i] != nil {
+			item.Status, item.Error = bulkItemStatus(errs[i]), errs[i].Error()
+			result.FailedIDs = append(result.FailedIDs, dto.ID)
+		} else {
+			recordHistory(c.Request.Context(), dto)
+			result.Saved++
+		}
+		result.Items[i] = item
+	}
+
+	status := http.StatusCreated
+	switch {
+	case result.Saved == 0 && len(result.FailedIDs) > 0:
+		status = firstFailureStatus(result.Items, errs)
+		slog.ErrorContext(c.Request.Context(), "Konnte keine Entität speichern", "failedIds", result.FailedIDs)
+	case len(result.FailedIDs) > 0:
+		status = http.StatusMultiStatus
+		slog.WarnContext(c.Request.Context(), "Entitäten teilweise nicht gespeichert", "saved", result.Saved, "failedIds", result.FailedIDs)
+	}
+
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(c.Request.Context(), "Exiting POST /api/base/bulk", "source", source, "saved", result.Saved, "failed", len(result.FailedIDs))
+	c.JSON(status, result)
+}
+
+// upstreamItemError ist der Fehler eines Upstreams beim Übergeben einer Entität
+type upstreamItemError struct {
+	err error
+}
+
+func (e *upstreamItemError) Error() string {
+	return e.err.Error()
+}
+
+func (e *upstreamItemError) Unwrap() error {
+	return e.err
+}
+
+// bulkItemStatus bildet den Fehler einer Entität auf ihren Status ab
+func bulkItemStatus(err error) int {
+	var upstreamErr *upstreamItemError
+	if errors.As(err, &upstreamErr) {
+		return upstreamFailureStatus(upstreamErr.err)
+	}
+	if errors.Is(err, errAccessDenied) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, errRolledBack) {
+		return http.StatusFailedDependency
+	}
+	return http.StatusInternalServerError
+}
+
+// firstFailureStatus liefert den Status der ersten Entität, die nicht nur
+// wegen eines anderen Fehlers zurückgerollt wurde
+func firstFailureStatus(items []BulkItemResult, errs []error) int {
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, errRolledBack) {
+			return items[i].Status
+		}
+	}
+	return items[0].Status
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+)
+
+const (
+	computedPayloadLength = "payloadLength"
+	computedChecksum      = "checksum"
+)
+
+// parseComputedFields liest die Liste der berechneten Felder, z.B. "payloadLength,checksum"
+func parseComputedFields(fieldsStr string) []string {
+	var fields []string
+	for _, field := range strings.Split(fieldsStr, ",") {
+		field = strings.TrimSpace(field)
+		switch field {
+		case "":
+		case computedPayloadLength, computedChecksum:
+			fields = append(fields, field)
+		default:
+			slog.Warn("Unbekanntes berechnetes Feld wird ignoriert", "field", field)
+		}
+	}
+	return fields
+}
+
+// enrichEntities ergänzt die Entitäten beim Lesen um die konfigurierten
+// berechneten Felder. Gespeichert werden diese Felder nicht.
+func enrichEntities(dtos []BaseDto) {
+	for _, field := range config.ComputedFields {
+		for i := range dtos {
+			switch field {
+			case computedPayloadLength:
+				length := len(dtos[i].Payload)
+				dtos[i].PayloadLength = &length
+			case computedChecksum:
+				sum := sha256.Sum256([]byte(dtos[i].Payload))
+				dtos[i].Checksum = hex.EncodeToString(sum[:])
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// configDefaults enthält alle per viper.Unmarshal gelesenen Schlüssel mit
+// ihren Defaults. Nur registrierte Schlüssel werden aus der Umgebung gelesen.
+var configDefaults = map[string]any{
+	"requestdelay":                time.Duration(0),
+	"responsedelay":               time.Duration(0),
+	"upstreamservices":            "",
+	"payloadsize":                 100,
+	"hmacsecret":                  "",
+	"maxconcurrentrequests":       0,
+	"admissionmode":               "",
+	"delayfile":                   "",
+	"keep_history":                false,
+	"startupdelay":                time.Duration(0),
+	"warmuprequests":              0,
+	"compressaggregation":         false,
+	"accesscontrol":               false,
+	"idempotencyttl":              time.Duration(0),
+	"idempotencystore":            "",
+	"errorformat":                 "",
+	"datasource.host":             "",
+	"datasource.port":             "",
+	"datasource.dbname":           "",
+	"datasource.username":         "",
+	"datasource.password":         "",
+	"mongodb.host":                "",
+	"mongodb.port":                "27017",
+	"mongodb.dbname":              "microzoo",
+	"dbhealthcheckinterval":       5 * time.Second,
+	"dbreconnectmaxbackoff":       30 * time.Second,
+	"staleiferror":                time.Duration(0),
+	"cursorpagesize":              0,
+	"version":                     "",
+	"http10":                      false,
+	"diskthroughput":              0,
+	"diskseeklatency":             time.Duration(0),
+	"accesslogfile":               "",
+	"accesslogmaxsizemb":          100,
+	"accesslogmaxbackups":         3,
+	"globalbandwidthbytespersec":  0,
+	"otelendpoint":                "",
+	"shutdownmode":                "",
+	"shutdowndelay":               time.Duration(0),
+	"payloadcompressionthreshold": 0,
+	"dnsfailurerate":              0.0,
+	"entitylatencymetadata":       false,
+	"maxconnections":              0,
+	"customnotfound":              false,
+	"notfoundlistroutes":          false,
+	"ballast_bytes":               0,
+	"retrybudgetsize":             0,
+	"retrybudgetrefillpersec":     1.0,
+	"entitycountmode":             "",
+	"degradeconcurrencythreshold": 0,
+	"degradepayloadfactor":        0.5,
+	"tracebuffersize":             0,
+	"healthsummarytimeout":        2 * time.Second,
+	"serializationdelayperentity": time.Duration(0),
+	"maxconcurrentreads":          0,
+	"maxconcurrentwrites":         0,
+	"retryafterbase":              time.Duration(0),
+	"retryafterjitter":            time.Duration(0),
+	"encryptionkey":               "",
+	"upstreamtimeout":             5 * time.Second,
+	"servertiming":                false,
+	"upstreamconcurrency":         0,
+	"upstreamretries":             0,
+	"upstreamretrybackoff":        100 * time.Millisecond,
+	"breakerfailurethreshold":     0,
+	"breakercooldown":             30 * time.Second,
+	"tracingendpoint":             "",
+	"tracingsampleratio":          1.0,
+	"shutdowngraceperiod":         30 * time.Second,
+	"dbdriver":                    "",
+	"redis.addr":                  "",
+	"redis.password":              "",
+	"redis.db":                    0,
+	"dbmaxopenconns":              defaultDBMaxOpenConns,
+	"dbmaxidleconns":              defaultDBMaxIdleConns,
+	"dbconnmaxlifetime":           defaultDBConnMaxLifetime,
+	"tlscertfile":                 "",
+	"tlskeyfile":                  "",
+	"ratelimitrps":                0.0,
+	"ratelimitburst":              0,
+	"ratelimitperclient":          false,
+	"cachettl":                    time.Duration(0),
+	"errorrate":                   0.0,
+	"errorseed":                   0,
+	"delaydistribution":           "",
+	"delayspread":                 0.5,
+	"cpuworkmillis":               0,
+	"memorychurnbytes":            0,
+	"kafka_brokers":               "",
+	"kafka_topic":                 "",
+	"kafkafailmode":               "",
+	"kafka_consume_topic":         "",
+	"kafka_consumer_group":        "microzoo",
+	"grpc_port":                   "",
+	"grpcmaxconcurrentstreams":    0,
+	"maxrequestentitycount":       10000,
+	"maxrequestpayloadsize":       1 << 20,
+	"bulkfailurerate":             0.0,
+	"jwt_secret":                  "",
+	"api_keys":                    "",
+	"gzip":                        false,
+	"gzipminsize":                 defaultGzipMinSize,
+	"maxpayloadlength":            defaultMaxPayloadLength,
+	"idstrategy":                  "",
+	"randompayload":               false,
+	"payloadseed":                 0,
+	"upstreammode":                "",
+	"aggregationstrategy":         "",
+	"fault_injection_enabled":     false,
+	"pprof_enabled":               false,
+	"serverreadtimeout":           30 * time.Second,
+	"serverwritetimeout":          2 * time.Minute,
+	"serveridletimeout":           2 * time.Minute,
+	"mongoindexes":                false,
+	"mongotextindex":              false,
+}
+
+// stringToListHook zerlegt kommagetrennte Werte wie UPSTREAMSERVICES in Listen
+// und verwirft leere Einträge
+func stringToListHook() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf([]string{}) {
+			return data, nil
+		}
+		list := []string{}
+		for _, item := range strings.Split(data.(string), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+}
+
+// validateConfig lehnt ungültige und widersprüchliche Einstellungen ab und
+// liefert alle gefundenen Fehler gemeinsam
+func validateConfig() error {
+	var errs []error
+
+	durations := map[string]time.Duration{
+		"RequestDelay":                config.RequestDelay,
+		"ResponseDelay":               config.ResponseDelay,
+		"StartupDelay":                config.StartupDelay,
+		"IdempotencyTTL":              config.IdempotencyTTL,
+		"DBHealthCheckInterval":       config.DBHealthCheckInterval,
+		"StaleIfError":                config.StaleIfError,
+		"DiskSeekLatency":             config.DiskSeekLatency,
+		"ShutdownDelay":               config.ShutdownDelay,
+		"HealthSummaryTimeout":        config.HealthSummaryTimeout,
+		"SerializationDelayPerEntity": config.SerializationDelayPerEntity,
+		"RetryAfterBase":              config.RetryAfterBase,
+		"RetryAfterJitter":            config.RetryAfterJitter,
+		"UpstreamRetryBackoff":        config.UpstreamRetryBackoff,
+		"BreakerCooldown":             config.BreakerCooldown,
+		"ShutdownGracePeriod":         config.ShutdownGracePeriod,
+		"CacheTTL":                    config.CacheTTL,
+		"ServerReadTimeout":           config.ServerReadTimeout,
+		"ServerWriteTimeout":          config.ServerWriteTimeout,
+		"ServerIdleTimeout":           config.ServerIdleTimeout,
+	}
+	for _, name := range sortedKeys(durations) {
+		value := durations[name]
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s %s ist negativ", name, value))
+		}
+	}
+
+	ratios := map[string]float64{
+		"DNSFailureRate":       config.DNSFailureRate,
+		"DegradePayloadFactor": config.DegradePayloadFactor,
+		"TracingSampleRatio":   config.TracingSampleRatio,
+		"ErrorRate":            config.ErrorRate,
+		"BulkFailureRate":      config.BulkFailureRate,
+	}
+	for _, name := range sortedKeys(ratios) {
+		value := ratios[name]
+		if value < 0 || value > 1 {
+			errs = append(errs, fmt.Errorf("%s %g liegt nicht zwischen 0 und 1", name, value))
+		}
+	}
+
+	positives := map[string]int{
+		"DBMaxOpenConns":        config.DBMaxOpenConns,
+		"DBMaxIdleConns":        config.DBMaxIdleConns,
+		"RateLimitBurst":        config.RateLimitBurst,
+		"MaxRequestEntityCount": config.MaxRequestEntityCount,
+		"MaxRequestPayloadSize": config.MaxRequestPayloadSize,
+		"MaxPayloadLength":      config.MaxPayloadLength,
+	}
+	for _, name := range sortedKeys(positives) {
+		value := positives[name]
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s muss größer als 0 sein", name))
+		}
+	}
+
+	if config.PayloadSize < 0 {
+		errs = append(errs, fmt.Errorf("PayloadSize %d ist negativ", config.PayloadSize))
+	}
+	if config.RateLimitRPS < 0 {
+		errs = append(errs, fmt.Errorf("RateLimitRPS %g ist negativ", config.RateLimitRPS))
+	}
+	if config.DelaySpread < 0 {
+		errs = append(errs, fmt.Errorf("DelaySpread %g ist negativ", config.DelaySpread))
+	}
+	if config.UpstreamTimeout <= 0 {
+		errs = append(errs, errors.New("UpstreamTimeout muss positiv sein"))
+	}
+	if config.DBConnMaxLifetime <= 0 {
+		errs = append(errs, errors.New("DBConnMaxLifetime muss positiv sein"))
+	}
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLSCertFile und TLSKeyFile müssen gemeinsam gesetzt werden"))
+	}
+
+	// initDB kann nur eine Datenbank anbinden. Redis darf daneben gesetzt
+	// sein und hält dann nur die Idempotency-Keys.
+	var databases []string
+	if config.Datasource.Host != "" {
+		databases = append(databases, "DATASOURCE_HOST")
+	}
+	if config.MongoDB.Host != "" {
+		databases = append(databases, "MONGODB_HOST")
+	}
+	if len(databases) > 1 {
+		errs = append(errs, fmt.Errorf("nur eine Datenbank darf konfiguriert sein, gesetzt sind %s", strings.Join(databases, ", ")))
+	}
+
+	if len(databases) > 0 && config.Redis.Addr != "" && config.IdempotencyStore != idempotencyStoreDB {
+		errs = append(errs, fmt.Errorf("REDIS_ADDR neben %s wird nur mit IdempotencyStore=db verwendet", strings.Join(databases, ", ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortedKeys sorgt für eine stabile Reihenfolge der Fehlermeldungen
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
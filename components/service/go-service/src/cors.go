@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	corsAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsMaxAge         = "600"
+)
+
+// parseCORSOrigins liest die erlaubten Origins, z.B.
+// "https://dashboard.example.com,http://localhost:3000" oder "*"
+func parseCORSOrigins(originsStr string) []string {
+	var origins []string
+	for _, origin := range strings.Split(originsStr, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// corsOriginAllowed prüft den Origin gegen CORSAllowedOrigins
+func corsOriginAllowed(origin string) bool {
+	for _, allowed := range config.CORSAllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware setzt für Requests an /api/base von erlaubten Origins die
+// Access-Control-Header und beantwortet Preflight-Requests direkt. Sie muss
+// global registriert sein, weil für OPTIONS keine Route existiert.
+func corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !strings.HasPrefix(c.Request.URL.Path, "/api/base") {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if !corsOriginAllowed(origin) {
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Access-Control-Expose-Headers", strings.Join([]string{requestIDHeader, "Retry-After", idempotencyReplayedHeader}, ", "))
+		if c.Request.Method != http.MethodOptions || c.GetHeader("Access-Control-Request-Method") == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		c.Header("Access-Control-Allow-Methods", corsAllowedMethods)
+		if headers := c.GetHeader("Access-Control-Request-Headers"); headers != "" {
+			c.Header("Access-Control-Allow-Headers", headers)
+		}
+		c.Header("Access-Control-Max-Age", corsMaxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
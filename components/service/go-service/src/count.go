@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getCount liefert die Anzahl der gespeicherten Entitäten, ohne sie zu laden.
+// Ohne Datenbank ist das die konfigurierte EntityCount. Mit Zugriffskontrolle
+// zählen nur die Entitäten, die der Aufrufer sehen darf.
+func getCount(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "Entered GET /api/base/count")
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+
+	count, source := int64(config.EntityCount), "dummy"
+	if isDBActive() {
+		var err error
+		if config.AccessControl {
+			// Fremde Entitäten dürfen nicht mitgezählt werden, daher hier laden und filtern
+			var dtos []BaseDto
+			dtos, err = getAllFromDB(c.Request.Context(), 0, 0)
+			count = int64(len(filterAccessible(callerIdentity(c), dtos)))
+		} else {
+			count, err = countInDB(c.Request.Context())
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Konnte Entitäten nicht zählen", "error", err)
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		source = "repository"
+	}
+
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(c.Request.Context(), "Exiting GET /api/base/count", "source", source, "count", count)
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+)
+
+// burnCPU rechnet für etwa CPUWorkMillis SHA-256-Hashes, um echte CPU-Last zu
+// erzeugen. Wird der Request abgebrochen, endet die Arbeit vorzeitig.
+func burnCPU(ctx context.Context) {
+	if config.CPUWorkMillis <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(time.Duration(config.CPUWorkMillis) * time.Millisecond)
+	sum := sha256.Sum256(nil)
+	for time.Now().Before(deadline) {
+		if ctx.Err() != nil {
+			return
+		}
+		// Zwischen den Prüfungen einen Block Hashes rechnen, damit Uhr und
+		// Kontext nicht die meiste Zeit kosten
+		for i := 0; i < 1000; i++ {
+			sum = sha256.Sum256(sum[:])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CursorPage ist die Antwort von getAll bei Keyset-Paginierung
+type CursorPage struct {
+	XMLName    xml.Name  `json:"-" xml:"page"`
+	Items      []BaseDto `json:"items" xml:"items>entity"`
+	NextCursor string    `json:"nextCursor,omitempty" xml:"nextCursor,omitempty"`
+}
+
+func encodeCursor(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	lastID, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return string(lastID), nil
+}
+
+// requestedCursor liefert die zuletzt gesehene ID aus dem Query-Parameter
+// cursor. Paginiert wird nur, wenn CursorPageSize gesetzt ist und der Client
+// den Parameter mitschickt (leer für die erste Seite).
+func requestedCursor(c *gin.Context) (string, bool, error) {
+	cursor, present := c.GetQuery("cursor")
+	if config.CursorPageSize <= 0 || !present {
+		return "", false, nil
+	}
+	if cursor == "" {
+		return "", true, nil
+	}
+	lastID, err := decodeCursor(cursor)
+	return lastID, true, err
+}
+
+// newCursorPage baut eine Seite aus den gelesenen Entitäten. Der nächste
+// Cursor wird aus der letzten gelesenen (ungefilterten) Entität gebildet.
+func newCursorPage(read []BaseDto, visible []BaseDto) CursorPage {
+	page := CursorPage{Items: visible}
+	if page.Items == nil {
+		page.Items = []BaseDto{}
+	}
+	if len(read) == config.CursorPageSize {
+		page.NextCursor = encodeCursor(read[len(read)-1].ID)
+	}
+	return page
+}
+
+// dummyPageStart ermittelt den Index der ersten generierten Entität nach der
+// zuletzt gesehenen ID (Format go-<n>)
+func dummyPageStart(lastID string) int {
+	if lastID == "" {
+		return 1
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(lastID, "go-"))
+	if err != nil {
+		return 1
+	}
+	return index + 1
+}
@@ -0,0 +1,942 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	dbTimeout       = 5 * time.Second
+	mongoCollection = "base"
+
+	// Spalten der Tabelle base in der Reihenfolge, die scanBase erwartet
+	baseColumns = "id, name, payload, owner, updated_at, payload_iv"
+
+	// Defaults des SQL-Verbindungspools bei fehlender oder ungültiger Konfiguration
+	defaultDBMaxOpenConns    = 25
+	defaultDBMaxIdleConns    = 10
+	defaultDBConnMaxLifetime = 5 * time.Minute
+)
+
+// Verbindungen zur Datenbank; beim Reconnect werden sie unter dbLock ersetzt
+var (
+	dbLock      sync.RWMutex
+	sqlDB       *sql.DB
+	mongoClient *mongo.Client
+	redisClient *redis.Client
+	dbConnected atomic.Bool
+)
+
+// isDBActive entspricht der Prüfung auf ein vorhandenes Repository im BaseService.java
+func isDBActive() bool {
+	dbLock.RLock()
+	defer dbLock.RUnlock()
+	return sqlDB != nil || mongoClient != nil || redisClient != nil
+}
+
+// dbBackendName liefert den Namen des aktiven Backends für Diagnose-Endpunkte
+func dbBackendName() string {
+	switch {
+	case currentSQLDB() != nil:
+		return config.DBDriver
+	case currentRedisClient() != nil:
+		return "redis"
+	case currentMongoCollection() != nil:
+		return "mongodb"
+	}
+	return "none"
+}
+
+func currentSQLDB() *sql.DB {
+	dbLock.RLock()
+	defer dbLock.RUnlock()
+	return sqlDB
+}
+
+func currentMongoCollection() *mongo.Collection {
+	dbLock.RLock()
+	defer dbLock.RUnlock()
+	if mongoClient == nil {
+		return nil
+	}
+	return mongoClient.Database(config.MongoDB.DBName).Collection(mongoCollection)
+}
+
+func mongoURI() string {
+	return fmt.Sprintf("mongodb://%s:%s/%s", config.MongoDB.Host, config.MongoDB.Port, config.MongoDB.DBName)
+}
+
+// initDB stellt die Verbindung zur konfigurierten Datenbank her. Ist sie beim
+// Start nicht erreichbar, wird im Hintergrund mit Backoff neu verbunden.
+func initDB() {
+	switch {
+	case config.Datasource.Host != "":
+		db, err := sql.Open(config.DBDriver, sqlDSN())
+		if err != nil {
+			slog.Error("Konnte SQL-Verbindung nicht anlegen", "driver", config.DBDriver, "error", err)
+			os.Exit(1)
+		}
+		sqlDB = db
+	case config.MongoDB.Host != "":
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI()))
+		if err != nil {
+			slog.Error("Konnte MongoDB-Verbindung nicht anlegen", "error", err)
+			os.Exit(1)
+		}
+		mongoClient = client
+	case config.Redis.Addr != "":
+		redisClient = newRedisClient()
+	default:
+		return
+	}
+
+	if err := pingDB(); err != nil {
+		slog.Warn("Datenbank beim Start nicht erreichbar", "backend", dbBackendName(), "error", err)
+		go func() {
+			reconnectDB()
+			startDBMonitor()
+		}()
+		return
+	}
+
+	slog.Info("Connected to database", "backend", dbBackendName())
+	if db := currentSQLDB(); db != nil {
+		configureSQLPool(db)
+	}
+	dbConnected.Store(true)
+	prepareDB()
+	startDBMonitor()
+}
+
+// configureSQLPool begrenzt den Verbindungspool, damit der Service unter Last
+// nicht beliebig viele Verbindungen öffnet
+func configureSQLPool(db *sql.DB) {
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	slog.Info("Configured SQL connection pool", "maxOpenConns", config.DBMaxOpenConns,
+		"maxIdleConns", min(config.DBMaxIdleConns, config.DBMaxOpenConns),
+		"connMaxLifetime", config.DBConnMaxLifetime.String())
+}
+
+func pingDB() error {
+	return pingDBContext(context.Background())
+}
+
+// pingDBContext prüft die Verbindung zur Datenbank, höchstens für dbTimeout
+func pingDBContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	if db := currentSQLDB(); db != nil {
+		return db.PingContext(ctx)
+	}
+	if client := currentRedisClient(); client != nil {
+		return client.Ping(ctx).Err()
+	}
+	dbLock.RLock()
+	client := mongoClient
+	dbLock.RUnlock()
+	if client != nil {
+		return client.Ping(ctx, nil)
+	}
+	return nil
+}
+
+// errDBClosed meldet, dass closeDB während eines Reconnects gelaufen ist
+var errDBClosed = errors.New("database connection closed")
+
+// connectDB baut eine neue Verbindung auf und ersetzt die alte erst, wenn die
+// neue erreichbar ist. Wurde die Verbindung inzwischen mit closeDB geschlossen,
+// wird die neue verworfen und errDBClosed geliefert.
+func connectDB() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if currentSQLDB() != nil {
+		db, err := sql.Open(config.DBDriver, sqlDSN())
+		if err == nil {
+			err = db.PingContext(ctx)
+		}
+		if err != nil {
+			if db != nil {
+				db.Close()
+			}
+			return err
+		}
+		configureSQLPool(db)
+
+		dbLock.Lock()
+		old := sqlDB
+		if old == nil {
+			dbLock.Unlock()
+			db.Close()
+			return errDBClosed
+		}
+		sqlDB = db
+		dbLock.Unlock()
+		old.Close()
+		return nil
+	}
+
+	if currentRedisClient() != nil {
+		client := newRedisClient()
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return err
+		}
+
+		dbLock.Lock()
+		old := redisClient
+		if old == nil {
+			dbLock.Unlock()
+			client.Close()
+			return errDBClosed
+		}
+		redisClient = client
+		dbLock.Unlock()
+		old.Close()
+		return nil
+	}
+
+	dbLock.RLock()
+	closed := mongoClient == nil
+	dbLock.RUnlock()
+	if closed {
+		return errDBClosed
+	}
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI()))
+	if err == nil {
+		err = client.Ping(ctx, nil)
+	}
+	if err != nil {
+		if client != nil {
+			client.Disconnect(context.Background())
+		}
+		return err
+	}
+
+	dbLock.Lock()
+	old := mongoClient
+	if old == nil {
+		dbLock.Unlock()
+		client.Disconnect(context.Background())
+		return errDBClosed
+	}
+	mongoClient = client
+	dbLock.Unlock()
+	old.Disconnect(context.Background())
+	return nil
+}
+
+// reconnectDB versucht so lange eine neue Verbindung aufzubauen, bis sie
+// gelingt oder closeDB die Verbindung schließt. Die Wartezeit zwischen den Versuchen verdoppelt sich bis
+// DBReconnectMaxBackoff.
+func reconnectDB() {
+	dbConnected.Store(false)
+	backoff := time.Second
+
+	for attempt := 1; ; attempt++ {
+		err := connectDB()
+		if err == nil {
+			slog.Info("Reconnected to database", "backend", dbBackendName(), "attempts", attempt)
+			break
+		}
+		if errors.Is(err, errDBClosed) {
+			slog.Info("Stopping reconnect, database connection closed")
+			return
+		}
+		slog.Warn("Reconnect zur Datenbank fehlgeschlagen", "attempt", attempt, "backoff", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, config.DBReconnectMaxBackoff)
+	}
+
+	dbConnected.Store(true)
+	prepareDB()
+}
+
+// startDBMonitor prüft die Verbindung in festen Abständen und stößt bei einem
+// Verbindungsverlust den Reconnect an. Währenddessen meldet der Service DOWN.
+func startDBMonitor() {
+	if config.DBHealthCheckInterval <= 0 {
+		return
+	}
+
+	go func() {
+		for range time.Tick(config.DBHealthCheckInterval) {
+			if err := pingDB(); err != nil {
+				slog.Warn("Verbindung zur Datenbank verloren", "backend", dbBackendName(), "error", err)
+				reconnectDB()
+			}
+		}
+	}()
+}
+
+// closeDB schließt die Verbindung beim Herunterfahren. Danach gilt keine
+// Datenbank mehr als konfiguriert, sodass der Monitor keinen Reconnect startet.
+func closeDB() {
+	dbLock.Lock()
+	db, client, redisConn := sqlDB, mongoClient, redisClient
+	sqlDB, mongoClient, redisClient = nil, nil, nil
+	dbLock.Unlock()
+	dbConnected.Store(false)
+
+	if db != nil {
+		if err := db.Close(); err != nil {
+			slog.Warn("Konnte SQL-Verbindung nicht schließen", "driver", config.DBDriver, "error", err)
+			return
+		}
+		slog.Info("Closed database connection", "backend", config.DBDriver)
+	}
+	if redisConn != nil {
+		if err := redisConn.Close(); err != nil {
+			slog.Warn("Konnte Redis-Verbindung nicht schließen", "error", err)
+			return
+		}
+		slog.Info("Closed database connection", "backend", "redis")
+	}
+	if client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+		defer cancel()
+		if err := client.Disconnect(ctx); err != nil {
+			slog.Warn("Konnte MongoDB-Verbindung nicht trennen", "error", err)
+			return
+		}
+		slog.Info("Closed database connection", "backend", "mongodb")
+	}
+}
+
+// prepareDB legt die Tabelle an und befüllt die Datenbank analog zum
+// PopulateRepoService.java mit EntityCount Entitäten
+func prepareDB() {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if db := currentSQLDB(); db != nil {
+		_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS base (
+			id VARCHAR(255) PRIMARY KEY,
+			name VARCHAR(255),
+			payload `+textType()+`,
+			owner VARCHAR(255),
+			updated_at `+timestampType()+`,
+			payload_iv VARCHAR(255)
+		)`)
+		// Ältere PostgreSQL-Tabellen um neue Spalten ergänzen; MySQL-Tabellen
+		// werden immer vollständig angelegt
+		if err == nil && !isMySQL() {
+			_, err = db.ExecContext(ctx, "ALTER TABLE base ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE")
+		}
+		if err == nil && !isMySQL() {
+			_, err = db.ExecContext(ctx, "ALTER TABLE base ADD COLUMN IF NOT EXISTS payload_iv VARCHAR(255)")
+		}
+		if err != nil {
+			slog.Warn("Konnte Tabelle nicht anlegen", "error", err)
+			return
+		}
+	}
+	if collection := currentMongoCollection(); collection != nil && config.MongoIndexes {
+		ensureMongoIndexes(ctx, collection)
+	}
+
+	if usesPersistentIdempotencyStore() {
+		prepareIdempotencyStore(ctx)
+	}
+
+	count, err := countInDB(ctx)
+	if err != nil {
+		slog.Warn("Konnte Entitäten nicht zählen", "error", err)
+		return
+	}
+	if count >= int64(config.EntityCount) {
+		return
+	}
+
+	slog.Info("Creating entities in database", "count", int64(config.EntityCount)-count, "payloadSize", config.PayloadSize)
+	for id := count + 1; id <= int64(config.EntityCount); id++ {
+		if _, err := saveToDB(ctx, generateBaseDto(int(id), config.PayloadSize)); err != nil {
+			slog.Warn("Konnte Entität nicht anlegen", "error", err)
+			return
+		}
+	}
+}
+
+// ensureMongoIndexes legt den Index auf name und bei MongoTextIndex einen
+// Textindex an. Fehler werden nur protokolliert.
+func ensureMongoIndexes(ctx context.Context, collection *mongo.Collection) {
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		slog.Warn("Konnte MongoDB-Indizes nicht lesen", "error", err)
+		return
+	}
+	existing := map[string]bool{}
+	for _, spec := range specs {
+		existing[spec.Name] = true
+	}
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetName("name_1")},
+	}
+	if config.MongoTextIndex {
+		models = append(models, mongo.IndexModel{Keys: bson.D{{Key: "name", Value: "text"}}, Options: options.Index().SetName("name_text")})
+	}
+	for _, model := range models {
+		name := *model.Options.Name
+		if existing[name] {
+			slog.Info("MongoDB index already present", "index", name)
+			continue
+		}
+		if _, err := collection.Indexes().CreateOne(ctx, model); err != nil {
+			slog.Warn("Konnte MongoDB-Index nicht anlegen", "index", name, "error", err)
+			continue
+		}
+		slog.Info("Created MongoDB index", "index", name)
+	}
+}
+
+func countInDB(ctx context.Context) (int64, error) {
+	var count int64
+	var err error
+	ctx, span := startDBSpan(ctx, "count")
+	switch {
+	case currentSQLDB() != nil:
+		err = currentSQLDB().QueryRowContext(ctx, "SELECT COUNT(*) FROM base").Scan(&count)
+	case currentRedisClient() != nil:
+		count, err = countInRedis(ctx)
+	default:
+		count, err = currentMongoCollection().CountDocuments(ctx, bson.D{})
+	}
+	observeDBOperation("count", err)
+	endSpan(span, err)
+	return count, err
+}
+
+// getAllFromDB liest bis zu limit Entitäten ab offset, sortiert nach ID.
+// Ein limit von 0 liest alle Entitäten.
+func getAllFromDB(ctx context.Context, limit, offset int) ([]BaseDto, error) {
+	var dtos []BaseDto
+	var err error
+	ctx, span := startDBSpan(ctx, "getAll")
+	switch {
+	case currentSQLDB() != nil:
+		dtos, err = getAllFromSQL(ctx, limit, offset)
+	case currentRedisClient() != nil:
+		dtos, err = getAllFromRedis(ctx, limit, offset)
+	default:
+		dtos, err = getAllFromMongo(ctx, limit, offset)
+	}
+	observeDBOperation("getAll", err)
+	endSpan(span, err)
+	simulateDiskIO(ctx, dtos...)
+	if err != nil {
+		return nil, err
+	}
+	return dtos, decryptPayloads(dtos)
+}
+
+// saveToDB speichert die Entität und setzt dabei den Änderungszeitpunkt. Ist
+// ein EncryptionKey konfiguriert, wird die Payload verschlüsselt gespeichert,
+// zurückgegeben wird die Entität im Klartext.
+func saveToDB(ctx context.Context, dto BaseDto) (BaseDto, error) {
+	now := time.Now().UTC()
+	dto.UpdatedAt = &now
+	simulateDiskIO(ctx, dto)
+
+	stored, err := encryptPayload(dto)
+	if err != nil {
+		return BaseDto{}, err
+	}
+	ctx, span := startDBSpan(ctx, "save")
+	switch {
+	case currentSQLDB() != nil:
+		_, err = saveToSQL(ctx, stored)
+	case currentRedisClient() != nil:
+		_, err = saveToRedis(ctx, stored)
+	default:
+		_, err = saveToMongo(ctx, stored)
+	}
+	observeDBOperation("save", err)
+	endSpan(span, err)
+	return dto, err
+}
+
+// errRolledBack kennzeichnet Entitäten, deren Speicherung mit der
+// Transaktion zurückgerollt wurde
+var errRolledBack = errors.New("transaction rolled back")
+
+// rollBackBatch markiert alle noch fehlerfreien Entitäten mit errRolledBack,
+// sobald eine Entität des Batches fehlgeschlagen ist. Fehler aus tolerated
+// verwerfen den Batch nicht. Liefert true, wenn der Batch damit verworfen ist.
+func rollBackBatch(dtos []BaseDto, errs []error, tolerated ...error) bool {
+	failed := slices.IndexFunc(errs, func(err error) bool {
+		return err != nil && !slices.ContainsFunc(tolerated, func(target error) bool { return errors.Is(err, target) })
+	})
+	if failed < 0 {
+		return false
+	}
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = fmt.Errorf("%w after failure of %s", errRolledBack, dtos[failed].ID)
+		}
+	}
+	return true
+}
+
+// saveAllToDB speichert mehrere Entitäten wie saveToDB und liefert für jede
+// Entität den Fehler ihrer Speicherung (nil bei Erfolg). Scheitert die
+// Verschlüsselung einer Entität, wird keine gespeichert.
+func saveAllToDB(ctx context.Context, dtos []BaseDto) ([]BaseDto, []error) {
+	now := time.Now().UTC()
+	errs := make([]error, len(dtos))
+	var stored []BaseDto
+	var storedIndexes []int
+	for i := range dtos {
+		dtos[i].UpdatedAt = &now
+		simulateDiskIO(ctx, dtos[i])
+		encrypted, err := encryptPayload(dtos[i])
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		stored = append(stored, encrypted)
+		storedIndexes = append(storedIndexes, i)
+	}
+	if rollBackBatch(dtos, errs) || len(stored) == 0 {
+		return dtos, errs
+	}
+
+	var storeErrs []error
+	ctx, span := startDBSpan(ctx, "saveAll")
+	switch {
+	case currentSQLDB() != nil:
+		storeErrs = saveAllToSQL(ctx, stored)
+	case currentRedisClient() != nil:
+		storeErrs = repeatError(len(stored), saveAllToRedis(ctx, stored))
+	default:
+		storeErrs = saveAllToMongo(ctx, stored)
+	}
+	for j, err := range storeErrs {
+		errs[storedIndexes[j]] = err
+	}
+	err := errors.Join(storeErrs...)
+	observeDBOperation("saveAll", err)
+	endSpan(span, err)
+	return dtos, errs
+}
+
+// repeatError liefert err für jede von n Entitäten
+func repeatError(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func getAllFromSQL(ctx context.Context, limit, offset int) ([]BaseDto, error) {
+	// LIMIT NULL bedeutet in PostgreSQL keine Begrenzung, MySQL kennt dafür
+	// nur einen sehr großen Wert
+	limitArg := sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+	if isMySQL() && limit <= 0 {
+		limitArg = sql.NullInt64{Int64: math.MaxInt64, Valid: true}
+	}
+	rows, err := currentSQLDB().QueryContext(ctx, rebind("SELECT "+baseColumns+" FROM base ORDER BY id LIMIT $1 OFFSET $2"),
+		limitArg, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanBaseRows(rows)
+}
+
+// scanBaseRows liest alle Zeilen einer Abfrage auf die Tabelle base
+func scanBaseRows(rows *sql.Rows) ([]BaseDto, error) {
+	defer rows.Close()
+
+	var dtos []BaseDto
+	for rows.Next() {
+		dto, err := scanBase(rows)
+		if err != nil {
+			return nil, err
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos, rows.Err()
+}
+
+// scanBase liest eine Zeile mit den Spalten aus baseColumns
+func scanBase(row interface{ Scan(...any) error }) (BaseDto, error) {
+	var dto BaseDto
+	var name, payload, owner, payloadIV sql.NullString
+	var updatedAt sql.NullTime
+	if err := row.Scan(&dto.ID, &name, &payload, &owner, &updatedAt, &payloadIV); err != nil {
+		return BaseDto{}, err
+	}
+	dto.Name, dto.Payload, dto.Owner, dto.PayloadIV = name.String, payload.String, owner.String, payloadIV.String
+	if updatedAt.Valid {
+		dto.UpdatedAt = &updatedAt.Time
+	}
+	return dto, nil
+}
+
+// saveSQL legt eine Entität an oder überschreibt sie
+func saveSQL() string {
+	return rebind("INSERT INTO base (id, name, payload, owner, updated_at, payload_iv) VALUES ($1, $2, $3, $4, $5, $6) " +
+		upsertClause("id", "name", "payload", "owner", "updated_at", "payload_iv"))
+}
+
+func saveToSQL(ctx context.Context, dto BaseDto) (BaseDto, error) {
+	observePayloadSize("stored", dto)
+	_, err := currentSQLDB().ExecContext(ctx, saveSQL(),
+		dto.ID, dto.Name, dto.Payload, dto.Owner, dto.UpdatedAt, nullIfEmpty(dto.PayloadIV))
+	return dto, err
+}
+
+// saveAllToSQL speichert alle Entitäten in einer Transaktion. Schlägt eine
+// fehl, wird die Transaktion zurückgerollt; die übrigen erhalten dann
+// errRolledBack.
+func saveAllToSQL(ctx context.Context, dtos []BaseDto) []error {
+	tx, err := currentSQLDB().BeginTx(ctx, nil)
+	if err != nil {
+		return repeatError(len(dtos), err)
+	}
+	stmt, err := tx.PrepareContext(ctx, saveSQL())
+	if err != nil {
+		tx.Rollback()
+		return repeatError(len(dtos), err)
+	}
+	defer stmt.Close()
+
+	for i, dto := range dtos {
+		observePayloadSize("stored", dto)
+		_, err := stmt.ExecContext(ctx, dto.ID, dto.Name, dto.Payload, dto.Owner, dto.UpdatedAt, nullIfEmpty(dto.PayloadIV))
+		if err != nil {
+			tx.Rollback()
+			errs := repeatError(len(dtos), fmt.Errorf("%w after failure of %s", errRolledBack, dto.ID))
+			errs[i] = err
+			return errs
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return repeatError(len(dtos), err)
+	}
+	return make([]error, len(dtos))
+}
+
+func getAllFromMongo(ctx context.Context, limit, offset int) ([]BaseDto, error) {
+	// Ein Limit von 0 bedeutet in MongoDB keine Begrenzung
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)).SetSkip(int64(offset))
+	cursor, err := currentMongoCollection().Find(ctx, bson.D{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var dtos []BaseDto
+	if err := cursor.All(ctx, &dtos); err != nil {
+		return nil, err
+	}
+	return dtos, nil
+}
+
+func saveToMongo(ctx context.Context, dto BaseDto) (BaseDto, error) {
+	observePayloadSize("stored", dto)
+	_, err := currentMongoCollection().ReplaceOne(ctx, bson.M{"_id": dto.ID}, dto, options.Replace().SetUpsert(true))
+	return dto, err
+}
+
+// saveAllToMongo speichert alle Entitäten ganz oder gar nicht. Ohne Replica
+// Set kennt MongoDB keine Transaktionen, daher werden die bisherigen Stände
+// vorab gelesen und nach einem Fehler wiederhergestellt; die übrigen
+// Entitäten erhalten dann errRolledBack.
+func saveAllToMongo(ctx context.Context, dtos []BaseDto) []error {
+	collection := currentMongoCollection()
+	ids := make([]string, len(dtos))
+	models := make([]mongo.WriteModel, len(dtos))
+	for i, dto := range dtos {
+		observePayloadSize("stored", dto)
+		ids[i] = dto.ID
+		models[i] = mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": dto.ID}).SetReplacement(dto).SetUpsert(true)
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return repeatError(len(dtos), err)
+	}
+	var previous []BaseDto
+	if err := cursor.All(ctx, &previous); err != nil {
+		return repeatError(len(dtos), err)
+	}
+
+	_, err = collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+	if err == nil {
+		return make([]error, len(dtos))
+	}
+	restoreMongo(ctx, collection, ids, previous)
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		return repeatError(len(dtos), err)
+	}
+	failed := bulkErr.WriteErrors[0]
+	errs := repeatError(len(dtos), fmt.Errorf("%w after failure of %s", errRolledBack, dtos[failed.Index].ID))
+	errs[failed.Index] = failed
+	return errs
+}
+
+// restoreMongo setzt die Entitäten ids auf ihre Stände in previous zurück und
+// löscht die, die vorher nicht existierten
+func restoreMongo(ctx context.Context, collection *mongo.Collection, ids []string, previous []BaseDto) {
+	existed := make(map[string]BaseDto, len(previous))
+	for _, dto := range previous {
+		existed[dto.ID] = dto
+	}
+	models := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		if dto, ok := existed[id]; ok {
+			models[i] = mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": id}).SetReplacement(dto)
+		} else {
+			models[i] = mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": id})
+		}
+	}
+	if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		slog.ErrorContext(ctx, "Konnte Batch nicht zurückrollen", "ids", ids, "error", err)
+	}
+}
+
+func getOneFromDB(ctx context.Context, id string) (BaseDto, bool, error) {
+	var dto BaseDto
+	var found bool
+	var err error
+	ctx, span := startDBSpan(ctx, "getOne")
+	switch {
+	case currentSQLDB() != nil:
+		dto, found, err = getOneFromSQL(ctx, id)
+	case currentRedisClient() != nil:
+		dto, found, err = getOneFromRedis(ctx, id)
+	default:
+		dto, found, err = getOneFromMongo(ctx, id)
+	}
+	observeDBOperation("getOne", err)
+	endSpan(span, err)
+	simulateDiskIO(ctx, dto)
+	if err != nil || !found {
+		return dto, found, err
+	}
+	dtos := []BaseDto{dto}
+	if err := decryptPayloads(dtos); err != nil {
+		return BaseDto{}, false, err
+	}
+	return dtos[0], true, nil
+}
+
+func getOneFromSQL(ctx context.Context, id string) (BaseDto, bool, error) {
+	dto, err := scanBase(currentSQLDB().QueryRowContext(ctx, rebind("SELECT "+baseColumns+" FROM base WHERE id = $1"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return BaseDto{}, false, nil
+	}
+	if err != nil {
+		return BaseDto{}, false, err
+	}
+	return dto, true, nil
+}
+
+func getOneFromMongo(ctx context.Context, id string) (BaseDto, bool, error) {
+	var dto BaseDto
+	err := currentMongoCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&dto)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return BaseDto{}, false, nil
+	}
+	if err != nil {
+		return BaseDto{}, false, err
+	}
+	return dto, true, nil
+}
+
+// updateInDB ersetzt eine vorhandene Entität und meldet, ob sie vorhanden
+// war. Im Gegensatz zu saveToDB wird keine neue Entität angelegt.
+func updateInDB(ctx context.Context, dto BaseDto) (BaseDto, bool, error) {
+	now := time.Now().UTC()
+	dto.UpdatedAt = &now
+	simulateDiskIO(ctx, dto)
+
+	stored, err := encryptPayload(dto)
+	if err != nil {
+		return BaseDto{}, false, err
+	}
+	var found bool
+	ctx, span := startDBSpan(ctx, "update")
+	switch {
+	case currentSQLDB() != nil:
+		found, err = updateInSQL(ctx, stored)
+	case currentRedisClient() != nil:
+		found, err = updateInRedis(ctx, stored)
+	default:
+		found, err = updateInMongo(ctx, stored)
+	}
+	observeDBOperation("update", err)
+	endSpan(span, err)
+	return dto, found, err
+}
+
+func updateInSQL(ctx context.Context, dto BaseDto) (bool, error) {
+	observePayloadSize("stored", dto)
+	result, err := currentSQLDB().ExecContext(ctx,
+		rebind("UPDATE base SET name = $1, payload = $2, owner = $3, updated_at = $4, payload_iv = $5 WHERE id = $6"),
+		dto.Name, dto.Payload, dto.Owner, dto.UpdatedAt, nullIfEmpty(dto.PayloadIV), dto.ID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func updateInMongo(ctx context.Context, dto BaseDto) (bool, error) {
+	observePayloadSize("stored", dto)
+	result, err := currentMongoCollection().ReplaceOne(ctx, bson.M{"_id": dto.ID}, dto)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// deleteFromDB löscht die Entität und meldet, ob sie vorhanden war
+func deleteFromDB(ctx context.Context, id string) (bool, error) {
+	simulateDiskIO(ctx)
+	var found bool
+	var err error
+	ctx, span := startDBSpan(ctx, "delete")
+	switch {
+	case currentSQLDB() != nil:
+		found, err = deleteFromSQL(ctx, id)
+	case currentRedisClient() != nil:
+		found, err = deleteFromRedis(ctx, id)
+	default:
+		found, err = deleteFromMongo(ctx, id)
+	}
+	observeDBOperation("delete", err)
+	endSpan(span, err)
+	return found, err
+}
+
+func deleteFromSQL(ctx context.Context, id string) (bool, error) {
+	result, err := currentSQLDB().ExecContext(ctx, rebind("DELETE FROM base WHERE id = $1"), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func deleteFromMongo(ctx context.Context, id string) (bool, error) {
+	result, err := currentMongoCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// getPageFromDB liest bis zu limit Entitäten mit einer ID größer als afterID
+// (Keyset-Paginierung)
+func getPageFromDB(ctx context.Context, afterID string, limit int) ([]BaseDto, error) {
+	var dtos []BaseDto
+	var err error
+	ctx, span := startDBSpan(ctx, "getPage")
+	switch {
+	case currentSQLDB() != nil:
+		dtos, err = getPageFromSQL(ctx, afterID, limit)
+	case currentRedisClient() != nil:
+		dtos, err = getPageFromRedis(ctx, afterID, limit)
+	default:
+		dtos, err = getPageFromMongo(ctx, afterID, limit)
+	}
+	observeDBOperation("getPage", err)
+	endSpan(span, err)
+	simulateDiskIO(ctx, dtos...)
+	if err != nil {
+		return nil, err
+	}
+	return dtos, decryptPayloads(dtos)
+}
+
+func getPageFromSQL(ctx context.Context, afterID string, limit int) ([]BaseDto, error) {
+	rows, err := currentSQLDB().QueryContext(ctx,
+		rebind("SELECT "+baseColumns+" FROM base WHERE id > $1 ORDER BY id LIMIT $2"), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanBaseRows(rows)
+}
+
+func getPageFromMongo(ctx context.Context, afterID string, limit int) ([]BaseDto, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := currentMongoCollection().Find(ctx, bson.M{"_id": bson.M{"$gt": afterID}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var dtos []BaseDto
+	if err := cursor.All(ctx, &dtos); err != nil {
+		return nil, err
+	}
+	return dtos, nil
+}
+
+// getUpdatedSinceFromDB liest alle Entitäten, die nach since geändert wurden
+func getUpdatedSinceFromDB(ctx context.Context, since time.Time) ([]BaseDto, error) {
+	var dtos []BaseDto
+	var err error
+	ctx, span := startDBSpan(ctx, "getUpdatedSince")
+	switch {
+	case currentSQLDB() != nil:
+		dtos, err = getUpdatedSinceFromSQL(ctx, since)
+	case currentRedisClient() != nil:
+		dtos, err = getUpdatedSinceFromRedis(ctx, since)
+	default:
+		dtos, err = getUpdatedSinceFromMongo(ctx, since)
+	}
+	observeDBOperation("getUpdatedSince", err)
+	endSpan(span, err)
+	simulateDiskIO(ctx, dtos...)
+	if err != nil {
+		return nil, err
+	}
+	return dtos, decryptPayloads(dtos)
+}
+
+func getUpdatedSinceFromSQL(ctx context.Context, since time.Time) ([]BaseDto, error) {
+	rows, err := currentSQLDB().QueryContext(ctx, rebind("SELECT "+baseColumns+" FROM base WHERE updated_at > $1 ORDER BY id"), since)
+	if err != nil {
+		return nil, err
+	}
+	return scanBaseRows(rows)
+}
+
+func getUpdatedSinceFromMongo(ctx context.Context, since time.Time) ([]BaseDto, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := currentMongoCollection().Find(ctx, bson.M{"updatedAt": bson.M{"$gt": since}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var dtos []BaseDto
+	if err := cursor.All(ctx, &dtos); err != nil {
+		return nil, err
+	}
+	return dtos, nil
+}
+
+// nullIfEmpty speichert leere Strings als NULL
+func nullIfEmpty(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inFlightRequests zählt die gerade bearbeiteten Requests auf /api/base
+var inFlightRequests atomic.Int64
+
+// inFlightMiddleware führt inFlightRequests nach
+func inFlightMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inFlightRequests.Add(1)
+		defer inFlightRequests.Add(-1)
+		c.Next()
+	}
+}
+
+// degradePayloads kürzt die Payloads um DegradePayloadFactor, solange mehr als
+// DegradeConcurrencyThreshold Requests gleichzeitig bearbeitet werden, um
+// unter Last Bandbreite zu sparen
+func degradePayloads(ctx context.Context, dtos []BaseDto) {
+	if config.DegradeConcurrencyThreshold <= 0 {
+		return
+	}
+	inFlight := inFlightRequests.Load()
+	if inFlight <= int64(config.DegradeConcurrencyThreshold) {
+		return
+	}
+
+	slog.InfoContext(ctx, "Degrading payloads", "inFlight", inFlight)
+	for i := range dtos {
+		size := int(float64(len(dtos[i].Payload)) * config.DegradePayloadFactor)
+		dtos[i].Payload = dtos[i].Payload[:size]
+	}
+}
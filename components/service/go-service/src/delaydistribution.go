@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	delayDistributionConstant    = "constant"
+	delayDistributionUniform     = "uniform"
+	delayDistributionNormal      = "normal"
+	delayDistributionExponential = "exponential"
+)
+
+// parseDelayDistribution prüft die konfigurierte Verteilung und fällt bei
+// unbekannten Werten auf constant zurück
+func parseDelayDistribution(distributionStr string) string {
+	distribution := strings.ToLower(strings.TrimSpace(distributionStr))
+	switch distribution {
+	case delayDistributionConstant, delayDistributionUniform, delayDistributionNormal, delayDistributionExponential:
+		return distribution
+	case "":
+		return delayDistributionConstant
+	}
+	slog.Warn("Unbekannte DelayDistribution, verwende Default", "delayDistribution", distributionStr, "default", delayDistributionConstant)
+	return delayDistributionConstant
+}
+
+// sampleDelay zieht ein Delay aus der konfigurierten Verteilung mit mean als
+// Mittelwert:
+//   - constant: immer mean
+//   - uniform: gleichverteilt in mean ± DelaySpread*mean
+//   - normal: normalverteilt mit Standardabweichung DelaySpread*mean
+//   - exponential: exponentialverteilt, DelaySpread wird nicht verwendet
+//
+// Negative Werte werden auf 0 begrenzt.
+func sampleDelay(mean time.Duration) time.Duration {
+	if mean <= 0 {
+		return mean
+	}
+
+	var sample float64
+	switch config.DelayDistribution {
+	case delayDistributionUniform:
+		sample = float64(mean) * (1 + config.DelaySpread*(2*rand.Float64()-1))
+	case delayDistributionNormal:
+		sample = float64(mean) * (1 + config.DelaySpread*rand.NormFloat64())
+	case delayDistributionExponential:
+		sample = float64(mean) * rand.ExpFloat64()
+	default:
+		return mean
+	}
+	return time.Duration(max(0, sample))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Überschreibungen der Delays aus der Signaldatei; -1 bedeutet "nicht gesetzt"
+var (
+	requestDelayOverride  atomic.Int64
+	responseDelayOverride atomic.Int64
+)
+
+func init() {
+	requestDelayOverride.Store(-1)
+	responseDelayOverride.Store(-1)
+}
+
+func currentRequestDelay() time.Duration {
+	if override := requestDelayOverride.Load(); override >= 0 {
+		return sampleDelay(time.Duration(override))
+	}
+	return sampleDelay(config.RequestDelay)
+}
+
+func currentResponseDelay() time.Duration {
+	if override := responseDelayOverride.Load(); override >= 0 {
+		return sampleDelay(time.Duration(override))
+	}
+	return sampleDelay(config.ResponseDelay)
+}
+
+// readDelayFile liest die Delays aus der Signaldatei. Die Datei enthält entweder
+// nur eine Dauer (gilt als RequestDelay) oder Zeilen der Form
+// "requestDelay=100ms" bzw. "responseDelay=20ms".
+func readDelayFile(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("Konnte Delay-Datei nicht lesen", "path", path, "error", err)
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			key, value = "requestDelay", line
+		}
+		delay, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || delay < 0 {
+			slog.Warn("Ungültiger Wert in Delay-Datei", "path", path, "line", line)
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "requestdelay":
+			requestDelayOverride.Store(int64(delay))
+		case "responsedelay":
+			responseDelayOverride.Store(int64(delay))
+		default:
+			slog.Warn("Unbekannter Schlüssel in Delay-Datei", "path", path, "key", key)
+			continue
+		}
+		slog.Info("Applied delay from file", "key", key, "delay", delay.String())
+	}
+}
+
+// watchDelayFile liest die Signaldatei beim Start und bei jeder Änderung neu
+// ein. Überwacht wird das Verzeichnis, damit auch atomar ersetzte Dateien
+// (rename) erkannt werden.
+func watchDelayFile(path string) {
+	readDelayFile(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("Konnte Delay-Datei nicht überwachen", "path", path, "error", err)
+		return
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		slog.Warn("Konnte Delay-Datei nicht überwachen", "path", path, "error", err)
+		watcher.Close()
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == filepath.Clean(path) && event.Has(fsnotify.Write|fsnotify.Create) {
+					readDelayFile(path)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("Fehler beim Überwachen der Delay-Datei", "path", path, "error", err)
+			}
+		}
+	}()
+}
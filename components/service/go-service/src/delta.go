@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestedUpdatedSince liest den Query-Parameter updatedSince (RFC 3339)
+func requestedUpdatedSince(c *gin.Context) (time.Time, bool, error) {
+	sinceStr := c.Query("updatedSince")
+	if sinceStr == "" {
+		return time.Time{}, false, nil
+	}
+	since, err := time.Parse(time.RFC3339, sinceStr)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid updatedSince %q, expected RFC 3339", sinceStr)
+	}
+	return since, true, nil
+}
+
+// filterUpdatedSince behält nur Entitäten, die nach since geändert wurden.
+// Entitäten ohne Änderungszeitpunkt (z.B. generierte) gelten als unverändert.
+func filterUpdatedSince(dtos []BaseDto, since time.Time) []BaseDto {
+	var changed []BaseDto
+	for _, dto := range dtos {
+		if dto.UpdatedAt != nil && dto.UpdatedAt.After(since) {
+			changed = append(changed, dto)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StoreDigest ist die Antwort von /api/base/digest
+type StoreDigest struct {
+	Backend string `json:"backend"`
+	Count   int    `json:"count"`
+	Digest  string `json:"digest"`
+}
+
+// digestEntities hasht die nach ID sortierten Entitäten mit SHA-256. Neben der
+// ID gehen Name, Payload und Owner ein, damit auch abweichende Inhalte bei
+// gleichen IDs erkannt werden.
+func digestEntities(dtos []BaseDto) string {
+	sorted := append([]BaseDto{}, dtos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	hash := sha256.New()
+	for _, dto := range sorted {
+		for _, field := range []string{dto.ID, dto.Name, dto.Payload, dto.Owner} {
+			hash.Write([]byte(field))
+			hash.Write([]byte{0})
+		}
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// getDigest liefert einen Hash über den Inhalt des Stores, um Replikate auf
+// Abweichungen zu vergleichen. Mit Zugriffskontrolle gehen nur die für den
+// Aufrufer sichtbaren Entitäten ein. Ohne Datenbank wird über die generierten
+// Dummy-Entitäten gehasht.
+func getDigest(c *gin.Context) {
+	slog.DebugContext(c.Request.Context(), "Entered GET /api/base/digest")
+
+	var dtos []BaseDto
+	if isDBActive() {
+		var err error
+		dtos, err = getAllFromDB(c.Request.Context(), 0, 0)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Konnte Entitäten nicht aus der Datenbank lesen", "error", err)
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		dtos = filterAccessible(callerIdentity(c), dtos)
+	} else {
+		for i := 1; i <= config.EntityCount; i++ {
+			dtos = append(dtos, generateBaseDto(i, config.PayloadSize))
+		}
+	}
+
+	slog.DebugContext(c.Request.Context(), "Exiting GET /api/base/digest")
+	c.JSON(http.StatusOK, StoreDigest{
+		Backend: dbBackendName(),
+		Count:   len(dtos),
+		Digest:  digestEntities(dtos),
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// simulateDiskIO verzögert einen Speicherzugriff wie eine Festplatte: eine
+// feste Seek-Latenz plus die Übertragungszeit der Payload-Bytes bei
+// DiskThroughput Bytes pro Sekunde.
+func simulateDiskIO(ctx context.Context, dtos ...BaseDto) {
+	if config.DiskSeekLatency <= 0 && config.DiskThroughput <= 0 {
+		return
+	}
+
+	delay := config.DiskSeekLatency
+	if config.DiskThroughput > 0 {
+		size := 0
+		for _, dto := range dtos {
+			size += len(dto.Payload)
+		}
+		delay += time.Duration(float64(size) / float64(config.DiskThroughput) * float64(time.Second))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
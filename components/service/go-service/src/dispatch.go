@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Die Logik von GET und POST /api/base ist vom Transport getrennt, damit HTTP
+// und gRPC dieselben Regeln für Routing, Idempotenz, Zugriffskontrolle und
+// Seitengrößen anwenden. Die Handler lesen nur ihre Parameter und schreiben
+// die Antwort; Authentifizierung und Limiter laufen davor als Middleware bzw.
+// Interceptor (siehe grpcInterceptors).
+
+// statusError trägt den HTTP-Status eines Fehlers; gRPC leitet seinen Code
+// daraus ab
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func withStatus(status int, err error) error {
+	return &statusError{status: status, err: err}
+}
+
+// errorStatus liefert den Status eines Fehlers, ohne Angabe 500
+func errorStatus(err error) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	}
+	return http.StatusInternalServerError
+}
+
+// requestScope beschreibt den Aufrufer unabhängig vom Transport
+type requestScope struct {
+	ctx context.Context
+	// identity ist der Hash des API-Keys, siehe callerIdentity
+	identity string
+	// param liefert die Parameter für UpstreamRoutes
+	param func(name string) (string, bool)
+}
+
+func httpScope(c *gin.Context) requestScope {
+	return requestScope{ctx: c.Request.Context(), identity: callerIdentity(c), param: c.GetQuery}
+}
+
+// listQuery sind die Parameter einer Abfrage von /api/base
+type listQuery struct {
+	lastID      string
+	paged       bool
+	since       time.Time
+	incremental bool
+	limit       int
+	offset      int
+	text        string
+	// dummyShape liefert Anzahl und Payload-Größe der Dummy-Entitäten; sie
+	// wird nur ohne Datenbank und Upstreams abgefragt
+	dummyShape func() (int, int, error)
+}
+
+// listEntities lädt die Entitäten aus der Datenbank, von den Upstreams oder
+// generiert sie. Geliefert werden die gelesenen und die für den Aufrufer
+// sichtbaren, aufbereiteten Entitäten sowie die Quelle.
+func listEntities(scope requestScope, query listQuery) ([]BaseDto, []BaseDto, string, error) {
+	ctx := scope.ctx
+
+	var (
+		dtos   []BaseDto
+		source string
+	)
+	// Simuliere die Logik aus BaseService.java
+	if isDBActive() {
+		// 1. Fall: Datenbank ist konfiguriert
+		source = "repository"
+		slog.InfoContext(ctx, "Fetching entities from repository")
+		start := time.Now()
+		cacheKey := listCacheKey(query.lastID, query.paged, query.since, query.incremental, query.limit, query.offset)
+		cached := false
+		if dtos, cached = listCache.get(cacheKey); !cached {
+			var err error
+			switch {
+			case query.paged:
+				dtos, err = getPageFromDB(ctx, query.lastID, config.CursorPageSize)
+			case query.incremental:
+				dtos, err = getUpdatedSinceFromDB(ctx, query.since)
+			default:
+				dtos, err = getAllFromDB(ctx, query.limit, query.offset)
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Konnte Entitäten nicht aus der Datenbank lesen", "error", err)
+				return nil, nil, source, err
+			}
+			listCache.put(cacheKey, dtos)
+		}
+		stampFetchDuration(dtos, time.Since(start))
+	} else if upstreams := routeUpstreams(ctx, scope.param); len(upstreams) > 0 {
+		// 2. Fall: Upstream-Services sind konfiguriert
+		source = "upstream"
+		slog.InfoContext(ctx, "Fetching entities from upstream services", "upstreams", upstreams)
+		var err error
+		dtos, err = fetchFromUpstreams(ctx, upstreams)
+		if err != nil {
+			return nil, nil, source, withStatus(upstreamFailureStatus(err), err)
+		}
+		if query.incremental {
+			dtos = filterUpdatedSince(dtos, query.since)
+		}
+		if !query.paged {
+			dtos = pageOf(dtos, query.limit, query.offset)
+		}
+	} else {
+		// 3. Fall: Keine Datenbank, keine Upstream-Services (Generierung von Dummy-Daten)
+		source = "dummy"
+		slog.InfoContext(ctx, "Generating dummy entities")
+		count, size, err := query.dummyShape()
+		if err != nil {
+			return nil, nil, source, withStatus(http.StatusBadRequest, err)
+		}
+		first, last := query.offset+1, min(count, query.offset+query.limit)
+		if query.paged {
+			first = dummyPageStart(query.lastID)
+			last = min(count, first+config.CursorPageSize-1)
+		}
+		for i := first; i <= last; i++ {
+			start := time.Now()
+			dtos = append(dtos, generateBaseDto(i, size))
+			stampFetchDuration(dtos[len(dtos)-1:], time.Since(start))
+		}
+		if query.incremental {
+			dtos = filterUpdatedSince(dtos, query.since)
+		}
+	}
+
+	visible := rankByRelevance(filterAccessible(scope.identity, dtos), query.text)
+	degradePayloads(ctx, visible)
+	enrichEntities(visible)
+	compressEntityPayloads(ctx, visible)
+	observePayloadSizes("returned", visible)
+	return dtos, visible, source, nil
+}
+
+// createEntity legt eine Entität in der Datenbank oder bei den Upstreams an
+// und liefert das Ergebnis sowie die Quelle. Ohne beides wird die Entität nur
+// zurückgegeben.
+func createEntity(scope requestScope, baseDto BaseDto) (BaseDto, string, error) {
+	ctx := scope.ctx
+
+	assignID(&baseDto)
+	if err := validateBaseDto(baseDto); err != nil {
+		return BaseDto{}, "", withStatus(http.StatusBadRequest, err)
+	}
+	assignOwner(scope.identity, &baseDto)
+
+	result, source := baseDto, "none"
+	// Simuliere die Logik aus BaseService.java
+	if isDBActive() {
+		// 1. Fall: Datenbank ist konfiguriert
+		source = "repository"
+		allowed, err := canOverwrite(ctx, scope.identity, baseDto.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Konnte Entität nicht aus der Datenbank lesen", "id", baseDto.ID, "error", err)
+			return BaseDto{}, source, err
+		}
+		if !allowed {
+			return BaseDto{}, source, withStatus(http.StatusForbidden, fmt.Errorf("access to entity %s denied", baseDto.ID))
+		}
+
+		slog.InfoContext(ctx, "Saving entity in repository", "id", baseDto.ID)
+		if result, err = saveToDB(ctx, baseDto); err != nil {
+			slog.ErrorContext(ctx, "Konnte Entität nicht speichern", "id", baseDto.ID, "error", err)
+			return BaseDto{}, source, err
+		}
+		listCache.invalidate()
+		staleEntities.put(result)
+	} else if upstreams := routeUpstreams(ctx, scope.param); len(upstreams) > 0 {
+		// 2. Fall: Upstream-Services sind konfiguriert; Ergebnis ist vorerst die
+		// Antwort des letzten erfolgreichen Upstreams
+		source = "upstream"
+		applyRequestTransform(&baseDto)
+		slog.InfoContext(ctx, "Posting entity to upstream services", "id", baseDto.ID, "upstreams", upstreams)
+		for _, serviceURL := range upstreams {
+			echoed, err := withFailover(ctx, serviceURL, func(serviceURL string) (BaseDto, error) {
+				return postToUpstream(ctx, serviceURL, baseDto)
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "Konnte Entität nicht übergeben", "id", baseDto.ID, "upstream", serviceURL, "error", err)
+				return BaseDto{}, source, withStatus(upstreamFailureStatus(err), err)
+			}
+			result = echoed
+		}
+	}
+
+	if err := publishCreated(ctx, result); err != nil {
+		return BaseDto{}, source, err
+	}
+	recordHistory(ctx, result)
+	return result, source, nil
+}
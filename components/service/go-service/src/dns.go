@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/url"
+)
+
+// injectDNSFailure lässt die Namensauflösung von host mit der Quote
+// DNSFailureRate mit NXDOMAIN fehlschlagen
+func injectDNSFailure(ctx context.Context, host string) error {
+	if config.DNSFailureRate <= 0 || rand.Float64() >= config.DNSFailureRate {
+		return nil
+	}
+	slog.InfoContext(ctx, "Injecting DNS failure", "host", host)
+	return &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{
+		Err:        "no such host",
+		Name:       host,
+		IsNotFound: true,
+	}}
+}
+
+// injectUpstreamDNSFailure wendet injectDNSFailure auf den Host einer
+// Upstream-URL an. Aufgerufen wird vor jedem Aufruf statt beim Verbindungsaufbau,
+// damit die Quote auch bei wiederverwendeten Keep-Alive-Verbindungen gilt.
+func injectUpstreamDNSFailure(ctx context.Context, serviceURL string) error {
+	parsed, err := url.Parse(serviceURL)
+	if err != nil || parsed.Hostname() == "" {
+		return injectDNSFailure(ctx, serviceURL)
+	}
+	return injectDNSFailure(ctx, parsed.Hostname())
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// downtimeWindow ist ein täglich wiederkehrendes Wartungsfenster in UTC,
+// angegeben als Minuten seit Mitternacht. Ist End kleiner als Start, reicht
+// das Fenster über Mitternacht hinaus.
+type downtimeWindow struct {
+	Start int
+	End   int
+}
+
+// parseDowntimeWindows liest Wartungsfenster im Format "02:00-02:30,22:00-23:00"
+func parseDowntimeWindows(windowsStr string) []downtimeWindow {
+	var windows []downtimeWindow
+	for _, windowStr := range strings.Split(windowsStr, ",") {
+		windowStr = strings.TrimSpace(windowStr)
+		if windowStr == "" {
+			continue
+		}
+		startStr, endStr, found := strings.Cut(windowStr, "-")
+		start, startErr := time.Parse("15:04", startStr)
+		end, endErr := time.Parse("15:04", endStr)
+		if !found || startErr != nil || endErr != nil {
+			slog.Warn("Ungültiges Wartungsfenster wird ignoriert", "window", windowStr)
+			continue
+		}
+		windows = append(windows, downtimeWindow{
+			Start: start.Hour()*60 + start.Minute(),
+			End:   end.Hour()*60 + end.Minute(),
+		})
+	}
+	return windows
+}
+
+// activeDowntime prüft, ob now in einem Wartungsfenster liegt, und liefert
+// die verbleibende Dauer bis zu dessen Ende
+func activeDowntime(now time.Time) (time.Duration, bool) {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	minute := now.Hour()*60 + now.Minute()
+	for _, window := range config.DowntimeWindows {
+		var end time.Time
+		switch {
+		case window.Start <= window.End && minute >= window.Start && minute < window.End:
+			end = midnight.Add(time.Duration(window.End) * time.Minute)
+		case window.Start > window.End && minute >= window.Start:
+			end = midnight.AddDate(0, 0, 1).Add(time.Duration(window.End) * time.Minute)
+		case window.Start > window.End && minute < window.End:
+			end = midnight.Add(time.Duration(window.End) * time.Minute)
+		default:
+			continue
+		}
+		return end.Sub(now), true
+	}
+	return 0, false
+}
+
+// downtimeMiddleware beantwortet Requests während eines Wartungsfensters mit
+// 503 und gibt das Ende des Fensters im Retry-After-Header an
+func downtimeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		remaining, ok := activeDowntime(time.Now())
+		if !ok {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", strconv.Itoa(int(remaining.Round(time.Second).Seconds())))
+		abortWithError(c, http.StatusServiceUnavailable, "scheduled maintenance")
+	}
+}
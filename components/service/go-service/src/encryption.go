@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// payloadCipher verschlüsselt Payloads vor dem Speichern mit AES-GCM. Ohne
+// EncryptionKey bleibt es nil und Payloads werden im Klartext gespeichert.
+var payloadCipher cipher.AEAD
+
+// initEncryption liest den hex-kodierten AES-Schlüssel (16, 24 oder 32 Bytes).
+// Ein ungültiger Schlüssel beendet den Start, damit nicht versehentlich im
+// Klartext gespeichert wird.
+func initEncryption() {
+	if config.EncryptionKey == "" {
+		return
+	}
+	key, err := hex.DecodeString(config.EncryptionKey)
+	if err != nil {
+		slog.Error("Ungültiger EncryptionKey, erwartet wird ein hex-kodierter AES-Schlüssel", "error", err)
+		os.Exit(1)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		slog.Error("Ungültiger EncryptionKey", "error", err)
+		os.Exit(1)
+	}
+	payloadCipher, err = cipher.NewGCM(block)
+	if err != nil {
+		slog.Error("Konnte AES-GCM nicht initialisieren", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Encrypting payloads at rest", "algorithm", fmt.Sprintf("AES-%d", len(key)*8))
+}
+
+// encryptPayload verschlüsselt die Payload mit einem zufälligen IV, der mit
+// der Entität gespeichert wird
+func encryptPayload(dto BaseDto) (BaseDto, error) {
+	if payloadCipher == nil {
+		return dto, nil
+	}
+	iv := make([]byte, payloadCipher.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return BaseDto{}, fmt.Errorf("cannot generate IV for %s: %w", dto.ID, err)
+	}
+	ciphertext := payloadCipher.Seal(nil, iv, []byte(dto.Payload), []byte(dto.ID))
+	dto.Payload = base64.StdEncoding.EncodeToString(ciphertext)
+	dto.PayloadIV = base64.StdEncoding.EncodeToString(iv)
+	return dto, nil
+}
+
+// decryptPayloads entschlüsselt die Payloads gelesener Entitäten. Entitäten
+// ohne IV wurden unverschlüsselt gespeichert und bleiben unverändert.
+func decryptPayloads(dtos []BaseDto) error {
+	for i := range dtos {
+		if dtos[i].PayloadIV == "" {
+			continue
+		}
+		if payloadCipher == nil {
+			return fmt.Errorf("entity %s is encrypted but no EncryptionKey is configured", dtos[i].ID)
+		}
+		iv, err := base64.StdEncoding.DecodeString(dtos[i].PayloadIV)
+		if err != nil {
+			return fmt.Errorf("invalid IV of entity %s: %w", dtos[i].ID, err)
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(dtos[i].Payload)
+		if err != nil {
+			return fmt.Errorf("invalid ciphertext of entity %s: %w", dtos[i].ID, err)
+		}
+		if len(iv) != payloadCipher.NonceSize() {
+			return fmt.Errorf("invalid IV length of entity %s", dtos[i].ID)
+		}
+		plaintext, err := payloadCipher.Open(nil, iv, ciphertext, []byte(dtos[i].ID))
+		if err != nil {
+			return fmt.Errorf("cannot decrypt payload of entity %s, wrong EncryptionKey? %w", dtos[i].ID, err)
+		}
+		dtos[i].Payload = string(plaintext)
+		dtos[i].PayloadIV = ""
+	}
+	return nil
+}
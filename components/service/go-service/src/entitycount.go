@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	entityCountModeRandom  = "random"
+	entityCountModeRequest = "request"
+)
+
+// parseEntityCount liest EntityCount als feste Anzahl ("10") oder als Bereich
+// ("5..50") und liefert Unter- und Obergrenze
+func parseEntityCount(countStr string) (int, int) {
+	if countStr == "" {
+		return 1, 1
+	}
+	minStr, maxStr, isRange := strings.Cut(countStr, "..")
+	if !isRange {
+		maxStr = minStr
+	}
+	minCount, minErr := strconv.Atoi(strings.TrimSpace(minStr))
+	maxCount, maxErr := strconv.Atoi(strings.TrimSpace(maxStr))
+	if minErr != nil || maxErr != nil || minCount > maxCount {
+		slog.Warn("Konnte EntityCount nicht parsen, verwende 1", "entityCount", countStr)
+		return 1, 1
+	}
+	return minCount, maxCount
+}
+
+// requestEntityCount bestimmt die Anzahl der Dummy-Entitäten für einen Request.
+// Ist EntityCount ein Bereich, wird die Anzahl zufällig gewählt oder im Modus
+// request deterministisch aus der X-Request-Id abgeleitet.
+func requestEntityCount(c *gin.Context) int {
+	spread := config.EntityCount - config.EntityCountMin
+	if spread <= 0 {
+		return config.EntityCount
+	}
+
+	requestID := c.GetHeader("X-Request-Id")
+	if config.EntityCountMode == entityCountModeRequest && requestID != "" {
+		hash := fnv.New64a()
+		hash.Write([]byte(requestID))
+		return config.EntityCountMin + int(hash.Sum64()%uint64(spread+1))
+	}
+	return config.EntityCountMin + rand.Intn(spread+1)
+}
+
+// requestedDummyShape liefert Anzahl und Payload-Größe der Dummy-Entitäten
+// eines Requests. Die Query-Parameter count und size überschreiben
+// EntityCount und PayloadSize, dürfen aber MaxRequestEntityCount bzw.
+// MaxRequestPayloadSize nicht übersteigen.
+func requestedDummyShape(c *gin.Context) (int, int, error) {
+	count, size := requestEntityCount(c), config.PayloadSize
+	if _, present := c.GetQuery("count"); present {
+		var err error
+		if count, err = intQuery(c, "count", 0, 0); err != nil {
+			return 0, 0, err
+		}
+		if count > config.MaxRequestEntityCount {
+			return 0, 0, fmt.Errorf("count %d exceeds the maximum of %d", count, config.MaxRequestEntityCount)
+		}
+	}
+	if _, present := c.GetQuery("size"); present {
+		var err error
+		if size, err = intQuery(c, "size", 0, 0); err != nil {
+			return 0, 0, err
+		}
+		if size > config.MaxRequestPayloadSize {
+			return 0, 0, fmt.Errorf("size %d exceeds the maximum of %d", size, config.MaxRequestPayloadSize)
+		}
+	}
+	return count, size, nil
+}
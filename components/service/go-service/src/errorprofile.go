@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// weightedStatus ist ein Statuscode mit seiner relativen Wahrscheinlichkeit
+type weightedStatus struct {
+	Status int
+	Weight float64
+}
+
+// parseErrorProfiles liest Fehlerprofile im Format
+// "GET: 200=0.9,500=0.05,503=0.05; POST /api/base/: 201=0.9,500=0.1".
+// Der Schlüssel vor dem Doppelpunkt ist entweder eine HTTP-Methode oder
+// Methode und Route, die Profile werden durch Semikolons getrennt.
+func parseErrorProfiles(profilesStr string) map[string][]weightedStatus {
+	profiles := map[string][]weightedStatus{}
+	for _, profileStr := range strings.Split(profilesStr, ";") {
+		profileStr = strings.TrimSpace(profileStr)
+		if profileStr == "" {
+			continue
+		}
+		// Routen können selbst Doppelpunkte enthalten (/api/base/:id), daher
+		// trennt der letzte Doppelpunkt vor dem ersten Statuscode
+		sep := strings.LastIndex(profileStr[:max(strings.Index(profileStr, "="), 0)], ":")
+		if sep < 0 {
+			slog.Warn("Ungültiges Fehlerprofil wird ignoriert", "profile", profileStr)
+			continue
+		}
+		endpoint, codesStr := profileStr[:sep], profileStr[sep+1:]
+
+		var statuses []weightedStatus
+		for _, codeStr := range strings.Split(codesStr, ",") {
+			statusStr, weightStr, _ := strings.Cut(strings.TrimSpace(codeStr), "=")
+			status, err := strconv.Atoi(strings.TrimSpace(statusStr))
+			if err != nil || http.StatusText(status) == "" {
+				slog.Warn("Ungültiger Statuscode im Fehlerprofil", "status", statusStr, "endpoint", endpoint)
+				continue
+			}
+			weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+			if err != nil || weight < 0 {
+				slog.Warn("Ungültige Gewichtung im Fehlerprofil", "weight", weightStr, "endpoint", endpoint)
+				continue
+			}
+			statuses = append(statuses, weightedStatus{Status: status, Weight: weight})
+		}
+		if len(statuses) > 0 {
+			profiles[strings.ToUpper(strings.TrimSpace(endpoint))] = statuses
+		}
+	}
+	return profiles
+}
+
+// pickStatus wählt einen Statuscode gemäß den Gewichtungen
+func pickStatus(statuses []weightedStatus) int {
+	total := 0.0
+	for _, status := range statuses {
+		total += status.Weight
+	}
+	if total <= 0 {
+		return http.StatusOK
+	}
+
+	r := rand.Float64() * total
+	for _, status := range statuses {
+		if r < status.Weight {
+			return status.Status
+		}
+		r -= status.Weight
+	}
+	return statuses[len(statuses)-1].Status
+}
+
+// injectEndpointError bestimmt den Statuscode aus der Statussequenz des
+// Clients oder würfelt ihn gemäß dem Fehlerprofil des Endpunkts aus. Ist er
+// kein Erfolgscode, wird der Request mit diesem Status beendet und true
+// zurückgegeben. Ohne Sequenz und Profil greift ErrorRate. Aufgerufen wird
+// nach dem RequestDelay.
+func injectEndpointError(c *gin.Context) bool {
+	err := injectedFailure(c.Request.Context(), clientKey(c), c.Request.Method, c.FullPath())
+	if err == nil {
+		return false
+	}
+	respondError(c, errorStatus(err), err.Error())
+	return true
+}
+
+// injectedFailure ist der transportunabhängige Teil von injectEndpointError
+// und liefert den injizierten Fehler mit seinem Status
+func injectedFailure(ctx context.Context, client, method, route string) error {
+	status, ok := nextSequencedStatus(client)
+	if !ok {
+		statuses, found := config.ErrorProfiles[strings.ToUpper(method+" "+route)]
+		if !found {
+			statuses, found = config.ErrorProfiles[method]
+		}
+		if !found {
+			return randomFailure(ctx, method, route)
+		}
+		status = pickStatus(statuses)
+	}
+	if status < http.StatusBadRequest {
+		return nil
+	}
+	slog.InfoContext(ctx, "Injecting status", "status", status, "method", method, "path", route)
+	return withStatus(status, errors.New("injected status "+strconv.Itoa(status)))
+}
+
+var (
+	failureRandOnce sync.Once
+	failureRandLock sync.Mutex
+	failureRand     *rand.Rand
+	failureCount    atomic.Int64
+)
+
+// nextFailureRand liefert die nächste Zufallszahl für injizierte Fehler. Mit
+// ErrorSeed ist die Folge reproduzierbar.
+func nextFailureRand() float64 {
+	failureRandOnce.Do(func() {
+		seed := config.ErrorSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		failureRand = rand.New(rand.NewSource(seed))
+	})
+
+	failureRandLock.Lock()
+	defer failureRandLock.Unlock()
+	return failureRand.Float64()
+}
+
+// randomFailure liefert mit Wahrscheinlichkeit ErrorRate einen Fehler mit 500.
+// Mit ErrorSeed ist die Folge der Fehler reproduzierbar.
+func randomFailure(ctx context.Context, method, route string) error {
+	if config.ErrorRate <= 0 || nextFailureRand() >= config.ErrorRate {
+		return nil
+	}
+	slog.WarnContext(ctx, "Injecting random failure",
+		"method", method, "route", route, "injectedFailures", failureCount.Add(1))
+	return errors.New("injected failure")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errorFormatEnvelope = "envelope"
+	errorFormatProblem  = "problem"
+)
+
+// ProblemDetails entspricht dem Fehlerformat aus RFC 7807
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+// respondError schreibt eine Fehlerantwort im konfigurierten Format: entweder
+// als einfacher Umschlag {"error": ...} oder als application/problem+json.
+func respondError(c *gin.Context, status int, detail string) {
+	setRetryAfter(c, status)
+	if config.ErrorFormat != errorFormatProblem {
+		c.JSON(status, gin.H{"error": detail})
+		return
+	}
+
+	c.Render(status, problemRender{ProblemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.RequestURI(),
+	}})
+}
+
+// abortWithError beendet die Handler-Kette mit einer Fehlerantwort
+func abortWithError(c *gin.Context, status int, detail string) {
+	respondError(c, status, detail)
+	c.Abort()
+}
+
+// problemRender rendert ProblemDetails oder eine Erweiterung davon mit dem
+// Content-Type application/problem+json
+type problemRender struct {
+	problem any
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/problem+json")
+}
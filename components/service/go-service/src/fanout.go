@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// upstreamErrors fasst die Fehler mehrerer Upstream-Aufrufe zusammen
+type upstreamErrors []error
+
+func (e upstreamErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e upstreamErrors) Unwrap() []error {
+	return e
+}
+
+// fetchFromUpstreams ruft alle Upstreams parallel ab, höchstens
+// UpstreamConcurrency gleichzeitig. Jeder Aufruf erhält eine eigene Deadline
+// aus dem Request-Kontext, nach einem Failover also auch der Backup. Die
+// Ergebnisse werden in der Reihenfolge ihres Eintreffens gemäß
+// AggregationStrategy zusammengeführt. Schlagen Aufrufe fehl, werden alle
+// Fehler gemeinsam gemeldet; bei der Strategie first genügt ein erfolgreicher
+// Upstream.
+func fetchFromUpstreams(ctx context.Context, upstreams []string) ([]BaseDto, error) {
+	concurrency := config.UpstreamConcurrency
+	if concurrency <= 0 {
+		concurrency = len(upstreams)
+	}
+	slots := make(chan struct{}, concurrency)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results [][]BaseDto
+		errs    upstreamErrors
+	)
+	for _, serviceURL := range upstreams {
+		wg.Add(1)
+		go func(serviceURL string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			start := time.Now()
+			result, err := withFailover(ctx, serviceURL, func(serviceURL string) ([]BaseDto, error) {
+				callCtx, cancel := context.WithTimeout(ctx, config.UpstreamTimeout)
+				defer cancel()
+				return fetchFromUpstream(callCtx, serviceURL)
+			})
+			if err == nil {
+				stampFetchDuration(result, time.Since(start))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				slog.ErrorContext(ctx, "Konnte Entitäten nicht abrufen", "upstream", serviceURL, "error", err)
+				errs = append(errs, err)
+				return
+			}
+			results = append(results, result)
+		}(serviceURL)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 && (config.AggregationStrategy != aggregationStrategyFirst || len(results) == 0) {
+		return nil, errs
+	}
+	return aggregateUpstreamResults(results), nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	injectDelayHeader  = "X-Inject-Delay"
+	injectStatusHeader = "X-Inject-Status"
+
+	// Obergrenze für X-Inject-Delay, damit ein Request nicht unbegrenzt hängt
+	maxInjectedDelay = 5 * time.Minute
+)
+
+// parseInjectedFault liest Verzögerung und Status aus den Werten von
+// X-Inject-Delay und X-Inject-Status. Fehlt ein Wert, ist er 0.
+func parseInjectedFault(delayStr, statusStr string) (time.Duration, int, error) {
+	var delay time.Duration
+	if delayStr != "" {
+		var err error
+		delay, err = time.ParseDuration(delayStr)
+		if err != nil || delay < 0 || delay > maxInjectedDelay {
+			return 0, 0, errors.New("invalid " + injectDelayHeader + " " + strconv.Quote(delayStr))
+		}
+	}
+	var status int
+	if statusStr != "" {
+		var err error
+		status, err = strconv.Atoi(statusStr)
+		if err != nil || status < http.StatusBadRequest || http.StatusText(status) == "" {
+			return 0, 0, errors.New("invalid " + injectStatusHeader + " " + strconv.Quote(statusStr) + ", expected a 4xx or 5xx status")
+		}
+	}
+	return delay, status, nil
+}
+
+// applyInjectedFault wartet die Verzögerung ab und liefert den injizierten
+// Status als Fehler. Ein abgebrochener Request liefert den Fehler des Kontexts.
+func applyInjectedFault(ctx context.Context, delay time.Duration, status int) error {
+	if delay > 0 {
+		slog.InfoContext(ctx, "Injecting delay", "delay", delay.String())
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if status > 0 {
+		slog.InfoContext(ctx, "Injecting status", "status", status)
+		return withStatus(status, fmt.Errorf("injected status %d", status))
+	}
+	return nil
+}
+
+// faultInjectionMiddleware verzögert einen Request um X-Inject-Delay (z.B.
+// "500ms") und beantwortet ihn mit X-Inject-Status (z.B. "503"), bevor der
+// Handler läuft. Ungültige Werte werden mit 400 abgelehnt.
+func faultInjectionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		delay, status, err := parseInjectedFault(c.GetHeader(injectDelayHeader), c.GetHeader(injectStatusHeader))
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := applyInjectedFault(c.Request.Context(), delay, status); err != nil {
+			if c.Request.Context().Err() != nil {
+				c.Abort()
+				return
+			}
+			abortWithError(c, errorStatus(err), err.Error())
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// baseDtoFields enthält die JSON-Feldnamen von BaseDto in Struct-Reihenfolge
+var baseDtoFields = jsonFieldNames(reflect.TypeOf(BaseDto{}))
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseFieldOrder liest die gewünschte Feldreihenfolge, z.B. "payload,name,id"
+func parseFieldOrder(orderStr string) []string {
+	var order []string
+	for _, field := range strings.Split(orderStr, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			order = append(order, field)
+		}
+	}
+	return order
+}
+
+// MarshalJSON serialisiert BaseDto in der konfigurierten Feldreihenfolge.
+// Nicht aufgeführte Felder folgen in Struct-Reihenfolge. Ohne FieldOrder
+// entspricht die Ausgabe der Standardserialisierung.
+func (dto BaseDto) MarshalJSON() ([]byte, error) {
+	type plainBaseDto BaseDto
+	data, err := json.Marshal(plainBaseDto(dto))
+	if err != nil || len(config.FieldOrder) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeField := func(name string) {
+		value, ok := fields[name]
+		if !ok {
+			return
+		}
+		delete(fields, name)
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	for _, name := range config.FieldOrder {
+		writeField(name)
+	}
+	for _, name := range baseDtoFields {
+		writeField(name)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
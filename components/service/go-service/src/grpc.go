@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codalf/microzoo/go-service/proto/basepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Der Code in proto/basepb wird aus proto/base.proto generiert
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/codalf/microzoo/go-service --go-grpc_out=.. --go-grpc_opt=module=github.com/codalf/microzoo/go-service base.proto
+
+// grpcServer ist nur gesetzt, wenn GRPCPort konfiguriert ist
+var grpcServer *grpc.Server
+
+// grpcBaseService implementiert microzoo.BaseService aus proto/base.proto
+// über dieselben Helfer wie die HTTP-Handler
+type grpcBaseService struct {
+	basepb.UnimplementedBaseServiceServer
+}
+
+// grpcScope liest API-Key und Routing-Parameter aus den Metadaten des Aufrufs
+func grpcScope(ctx context.Context) requestScope {
+	md, _ := metadata.FromIncomingContext(ctx)
+	param := func(name string) (string, bool) {
+		values := md.Get(name)
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	}
+	return requestScope{ctx: ctx, identity: apiKeyIdentity(grpcMetadata(ctx, "x-api-key")), param: param}
+}
+
+// injectGRPCError entspricht injectEndpointError für die REST-Route der Methode
+func injectGRPCError(ctx context.Context, fullMethod string) error {
+	route := grpcRoutes[fullMethod]
+	if err := injectedFailure(ctx, grpcClientKey(ctx), route.method, route.route); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+// GetAll entspricht GET /api/base mit limit und offset
+func (grpcBaseService) GetAll(ctx context.Context, req *basepb.GetAllRequest) (*basepb.GetAllResponse, error) {
+	slog.DebugContext(ctx, "Entered gRPC GetAll", "limit", req.GetLimit(), "offset", req.GetOffset())
+	time.Sleep(currentRequestDelay())
+
+	if err := injectGRPCError(ctx, basepb.BaseService_GetAll_FullMethodName); err != nil {
+		return nil, err
+	}
+
+	query := listQuery{limit: int(req.GetLimit()), offset: max(int(req.GetOffset()), 0)}
+	if query.limit <= 0 {
+		query.limit = defaultPageLimit
+	}
+	query.dummyShape = func() (int, int, error) {
+		return config.EntityCount, config.PayloadSize, nil
+	}
+
+	_, visible, source, err := listEntities(grpcScope(ctx), query)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &basepb.GetAllResponse{Entities: make([]*basepb.BaseDto, 0, len(visible))}
+	for _, dto := range visible {
+		resp.Entities = append(resp.Entities, toProtoBaseDto(dto))
+	}
+
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(ctx, "Exiting gRPC GetAll", "source", source, "count", len(visible))
+	return resp, nil
+}
+
+// Create entspricht POST /api/base, der Idempotency-Key wird als Metadatum
+// idempotency-key übergeben
+func (grpcBaseService) Create(ctx context.Context, req *basepb.BaseDto) (*basepb.BaseDto, error) {
+	slog.DebugContext(ctx, "Entered gRPC Create", "id", req.GetId())
+	time.Sleep(currentRequestDelay())
+
+	if err := injectGRPCError(ctx, basepb.BaseService_Create_FullMethodName); err != nil {
+		return nil, err
+	}
+
+	baseDto, err := fromProtoBaseDto(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	scope := grpcScope(ctx)
+	key, _ := scope.param(strings.ToLower(idempotencyKeyHeader))
+	key = scopedIdempotencyKey(scope.identity, key)
+	record, replayed, err := reserveIdempotency(ctx, key)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if replayed {
+		return toProtoBaseDto(record.Body), nil
+	}
+
+	result, source, err := createEntity(scope, baseDto)
+	if err != nil {
+		releaseIdempotency(key)
+		return nil, grpcError(err)
+	}
+	completeIdempotency(key, result)
+
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(ctx, "Exiting gRPC Create", "source", source, "id", result.ID)
+	return toProtoBaseDto(result), nil
+}
+
+func toProtoBaseDto(dto BaseDto) *basepb.BaseDto {
+	message := &basepb.BaseDto{Id: dto.ID, Name: dto.Name, Payload: dto.Payload, Owner: dto.Owner}
+	if dto.UpdatedAt != nil {
+		message.UpdatedAt = dto.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return message
+}
+
+func fromProtoBaseDto(message *basepb.BaseDto) (BaseDto, error) {
+	dto := BaseDto{ID: message.GetId(), Name: message.GetName(), Payload: message.GetPayload(), Owner: message.GetOwner()}
+	if message.GetUpdatedAt() != "" {
+		updatedAt, err := time.Parse(time.RFC3339Nano, message.GetUpdatedAt())
+		if err != nil {
+			return BaseDto{}, fmt.Errorf("invalid updated_at %q: %w", message.GetUpdatedAt(), err)
+		}
+		dto.UpdatedAt = &updatedAt
+	}
+	return dto, nil
+}
+
+// grpcError bildet den HTTP-Status eines Fehlers auf einen gRPC-Statuscode ab
+func grpcError(err error) error {
+	code := codes.Internal
+	switch errorStatus(err) {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
+
+// startGRPCServer stellt BaseService auf GRPCPort bereit. Vor den Methoden
+// laufen dieselben Limiter wie vor /api/base. Ohne GRPCPort bleibt der
+// gRPC-Endpunkt abgeschaltet.
+func startGRPCServer(limits apiLimits) {
+	if config.GRPCPort == "" {
+		return
+	}
+	listener, err := net.Listen("tcp", ":"+config.GRPCPort)
+	if err != nil {
+		slog.Error("Konnte gRPC-Server nicht starten", "port", config.GRPCPort, "error", err)
+		os.Exit(1)
+	}
+
+	options := []grpc.ServerOption{grpc.ChainUnaryInterceptor(grpcInterceptors(limits)...)}
+	if config.GRPCMaxConcurrentStreams > 0 {
+		options = append(options, grpc.MaxConcurrentStreams(uint32(config.GRPCMaxConcurrentStreams)))
+	}
+	grpcServer = grpc.NewServer(options...)
+	basepb.RegisterBaseServiceServer(grpcServer, grpcBaseService{})
+
+	go func() {
+		slog.Info("Serving gRPC", "port", config.GRPCPort, "maxConcurrentStreams", config.GRPCMaxConcurrentStreams)
+		if err := grpcServer.Serve(listener); err != nil {
+			slog.Error("gRPC-Server beendet", "error", err)
+		}
+	}()
+}
+
+// stopGRPCServer lässt laufende RPCs höchstens ShutdownGracePeriod lang
+// auslaufen und bricht sie danach ab
+func stopGRPCServer() {
+	if grpcServer == nil {
+		return
+	}
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		slog.Info("gRPC server stopped")
+	case <-time.After(config.ShutdownGracePeriod):
+		slog.Warn("Grace Period abgelaufen, breche laufende gRPC-Aufrufe ab")
+		grpcServer.Stop()
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codalf/microzoo/go-service/proto/basepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcRoutes ordnet die gRPC-Methoden den entsprechenden REST-Routen zu, damit
+// Fehlerprofile und Methodenlimits für beide Transporte gleich greifen
+var grpcRoutes = map[string]struct{ method, route string }{
+	basepb.BaseService_GetAll_FullMethodName: {http.MethodGet, "/api/base/"},
+	basepb.BaseService_Create_FullMethodName: {http.MethodPost, "/api/base/"},
+}
+
+// grpcInterceptors entspricht der Middleware-Kette der Gruppe /api/base
+func grpcInterceptors(limits apiLimits) []grpc.UnaryServerInterceptor {
+	var interceptors []grpc.UnaryServerInterceptor
+	if config.JWTSecret != "" {
+		interceptors = append(interceptors, grpcJWTInterceptor)
+	}
+	if len(config.APIKeys) > 0 {
+		interceptors = append(interceptors, grpcAPIKeyInterceptor)
+	}
+	if len(config.DowntimeWindows) > 0 {
+		interceptors = append(interceptors, grpcDowntimeInterceptor)
+	}
+	if limits.rateLimiter != nil {
+		interceptors = append(interceptors, grpcRateLimitInterceptor(limits.rateLimiter))
+	}
+	if config.DegradeConcurrencyThreshold > 0 {
+		interceptors = append(interceptors, grpcInFlightInterceptor)
+	}
+	if limits.scheduler != nil {
+		interceptors = append(interceptors, grpcAdmissionInterceptor(limits.scheduler))
+	}
+	if limits.methods != nil {
+		interceptors = append(interceptors, grpcMethodLimitInterceptor(limits.methods))
+	}
+	if config.FaultInjectionEnabled {
+		interceptors = append(interceptors, grpcFaultInjectionInterceptor)
+	}
+	return interceptors
+}
+
+// grpcMetadata liefert den ersten Wert eines Metadatums
+func grpcMetadata(ctx context.Context, name string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcClientKey identifiziert den Client wie clientKey über seinen API-Key
+// oder seine IP-Adresse
+func grpcClientKey(ctx context.Context) string {
+	if apiKey := grpcMetadata(ctx, "x-api-key"); apiKey != "" {
+		return apiKey
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// setGRPCRetryAfter gibt die Wartezeit wie der Retry-After-Header als
+// Metadatum retry-after zurück
+func setGRPCRetryAfter(ctx context.Context, wait time.Duration) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(wait))); err != nil {
+		slog.DebugContext(ctx, "Could not set retry-after metadata", "error", err)
+	}
+}
+
+// grpcJWTInterceptor verlangt wie jwtMiddleware ein gültiges HS256-Bearer-Token,
+// hier im Metadatum authorization
+func grpcJWTInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	token, found := strings.CutPrefix(grpcMetadata(ctx, "authorization"), "Bearer ")
+	if !found {
+		return nil, status.Error(codes.Unauthenticated, "bearer token required")
+	}
+	claims, err := verifyJWT(strings.TrimSpace(token), time.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "Token abgelehnt", "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token: "+err.Error())
+	}
+	return handler(context.WithValue(ctx, jwtSubjectKey{}, claims.Subject), req)
+}
+
+// grpcAPIKeyInterceptor verlangt wie apiKeyMiddleware einen der konfigurierten
+// API-Keys, hier im Metadatum x-api-key
+func grpcAPIKeyInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !validAPIKey(grpcMetadata(ctx, "x-api-key")) {
+		return nil, status.Error(codes.Unauthenticated, "valid x-api-key required")
+	}
+	return handler(ctx, req)
+}
+
+func grpcDowntimeInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	remaining, ok := activeDowntime(time.Now())
+	if !ok {
+		return handler(ctx, req)
+	}
+	setGRPCRetryAfter(ctx, remaining)
+	return nil, status.Error(codes.Unavailable, "scheduled maintenance")
+}
+
+func grpcRateLimitInterceptor(limiter *rateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		bucket := limiter.bucket(grpcClientKey(ctx))
+		if bucket.tryTake() {
+			return handler(ctx, req)
+		}
+		setGRPCRetryAfter(ctx, withRetryJitter(bucket.nextTokenIn()))
+		slog.InfoContext(ctx, "Rejecting gRPC call, rate limit reached", "method", info.FullMethod, "rps", config.RateLimitRPS)
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+}
+
+func grpcInFlightInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	inFlightRequests.Add(1)
+	defer inFlightRequests.Add(-1)
+	return handler(ctx, req)
+}
+
+func grpcAdmissionInterceptor(scheduler *fairScheduler) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		client := grpcClientKey(ctx)
+		queue := ""
+		if config.AdmissionMode == admissionModeFair {
+			queue = client
+		}
+
+		start := time.Now()
+		if err := scheduler.acquire(ctx, queue); err != nil {
+			return nil, status.Error(codes.Unavailable, "request cancelled while waiting for admission")
+		}
+		label := clientLabel(client)
+		admissionWaitSeconds.WithLabelValues(label).Observe(time.Since(start).Seconds())
+		defer scheduler.release()
+
+		started := time.Now()
+		defer func() {
+			admissionServiceSeconds.WithLabelValues(label).Observe(time.Since(started).Seconds())
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func grpcMethodLimitInterceptor(limiter *methodLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		write := grpcRoutes[info.FullMethod].method != http.MethodGet
+		release, kind, ok := limiter.tryAcquire(write)
+		if !ok {
+			slog.InfoContext(ctx, "Rejecting gRPC call, concurrency limit reached", "method", info.FullMethod, "kind", kind, "limit", limiter.limit(write))
+			return nil, status.Error(codes.Unavailable, kind+" concurrency limit reached")
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// grpcFaultInjectionInterceptor liest X-Inject-Delay und X-Inject-Status aus
+// den Metadaten x-inject-delay und x-inject-status
+func grpcFaultInjectionInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	delay, injected, err := parseInjectedFault(grpcMetadata(ctx, "x-inject-delay"), grpcMetadata(ctx, "x-inject-status"))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := applyInjectedFault(ctx, delay, injected); err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, grpcError(err)
+	}
+	return handler(ctx, req)
+}
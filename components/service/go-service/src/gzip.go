@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultGzipMinSize = 1024
+
+// gzipMiddleware komprimiert Antworten ab GzipMinSize Bytes, wenn der Client
+// gzip akzeptiert. Bereits kodierte Antworten, etwa vorab komprimierte
+// Aggregationen, werden unverändert durchgereicht.
+func gzipMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c) {
+			c.Next()
+			return
+		}
+		writer := newBufferedResponseWriter(c.Writer)
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		header := c.Writer.Header()
+		if header.Get("Content-Encoding") != "" {
+			writer.flush()
+			return
+		}
+		header.Add("Vary", "Accept-Encoding")
+		if writer.body.Len() < config.GzipMinSize {
+			writer.flush()
+			return
+		}
+
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		_, err := gz.Write(writer.body.Bytes())
+		if err == nil {
+			err = gz.Close()
+		}
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Konnte Antwort nicht komprimieren", "error", err)
+			writer.flush()
+			return
+		}
+
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(compressed.Bytes())
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// liveness meldet nur, dass der Prozess läuft und Requests beantwortet. Sie
+// ist auch unter /actuator/health erreichbar.
+func liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "UP"})
+}
+
+// readiness meldet UP, sobald der Service Requests bearbeiten kann. Ist eine
+// Datenbank konfiguriert, wird die Verbindung bei jedem Aufruf neu geprüft.
+func readiness(c *gin.Context) {
+	if !serviceReady.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "reason": "warming up or shutting down"})
+		return
+	}
+	if isDBActive() {
+		if !dbConnected.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "reason": "database not connected"})
+			return
+		}
+		if err := pingDBContext(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "reason": "database ping failed: " + err.Error()})
+			return
+		}
+	}
+	if _, ok := activeDowntime(time.Now()); ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "OUT_OF_SERVICE", "reason": "scheduled maintenance"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "UP"})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthGreen  = "green"
+	healthYellow = "yellow"
+	healthRed    = "red"
+)
+
+// ComponentHealth ist der Zustand einer einzelnen Komponente
+type ComponentHealth struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// HealthSummary ist die Antwort von /actuator/health/summary
+type HealthSummary struct {
+	Color      string                     `json:"color"`
+	Components map[string]ComponentHealth `json:"components"`
+	Upstreams  map[string]ComponentHealth `json:"upstreams,omitempty"`
+}
+
+// summaryUpstreams liefert alle Upstreams, die der Service aufrufen kann
+func summaryUpstreams() []string {
+	seen := map[string]bool{}
+	var upstreams []string
+	add := func(serviceURL string) {
+		if serviceURL != "" && !seen[serviceURL] {
+			seen[serviceURL] = true
+			upstreams = append(upstreams, serviceURL)
+		}
+	}
+	for _, serviceURL := range config.UpstreamServices {
+		add(serviceURL)
+	}
+	for _, route := range config.UpstreamRoutes {
+		add(route.URL)
+	}
+	return upstreams
+}
+
+// probeUpstream fragt den Health-Endpunkt eines Upstream-Services ab
+func probeUpstream(ctx context.Context, serviceURL string) ComponentHealth {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(serviceURL, "/")+"/actuator/health", nil)
+	if err != nil {
+		return ComponentHealth{Status: "DOWN", Detail: err.Error()}
+	}
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return ComponentHealth{Status: "DOWN", Detail: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ComponentHealth{Status: "DOWN", Detail: resp.Status}
+	}
+	return ComponentHealth{Status: "UP"}
+}
+
+// healthSummary fasst Liveness, Readiness, Datenbank und Upstreams zusammen.
+// Rot ist der Knoten, wenn er selbst nicht bereit ist, die Datenbank fehlt
+// oder kein Upstream erreichbar ist; gelb, wenn einzelne Upstreams ausfallen.
+func healthSummary(c *gin.Context) {
+	summary := HealthSummary{Color: healthGreen, Components: map[string]ComponentHealth{
+		"liveness": {Status: "UP"},
+	}}
+	degrade := func(color string) {
+		if color == healthRed || summary.Color == healthGreen {
+			summary.Color = color
+		}
+	}
+
+	readiness := ComponentHealth{Status: "UP"}
+	if !serviceReady.Load() {
+		readiness = ComponentHealth{Status: "DOWN", Detail: "warming up or shutting down"}
+	} else if _, ok := activeDowntime(time.Now()); ok {
+		readiness = ComponentHealth{Status: "OUT_OF_SERVICE", Detail: "scheduled maintenance"}
+	}
+	if readiness.Status != "UP" {
+		degrade(healthRed)
+	}
+	summary.Components["readiness"] = readiness
+
+	if isDBActive() {
+		db := ComponentHealth{Status: "UP", Detail: dbBackendName()}
+		if !dbConnected.Load() {
+			db.Status = "DOWN"
+			degrade(healthRed)
+		}
+		summary.Components["db"] = db
+	}
+
+	upstreams := summaryUpstreams()
+	if len(upstreams) > 0 {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), config.HealthSummaryTimeout)
+		defer cancel()
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		summary.Upstreams = map[string]ComponentHealth{}
+		for _, serviceURL := range upstreams {
+			wg.Add(1)
+			go func(serviceURL string) {
+				defer wg.Done()
+				health := probeUpstream(ctx, serviceURL)
+				mu.Lock()
+				summary.Upstreams[serviceURL] = health
+				mu.Unlock()
+			}(serviceURL)
+		}
+		wg.Wait()
+
+		down := 0
+		for _, health := range summary.Upstreams {
+			if health.Status != "UP" {
+				down++
+			}
+		}
+		switch {
+		case down == len(upstreams):
+			degrade(healthRed)
+		case down > 0:
+			degrade(healthYellow)
+		}
+	}
+
+	status := http.StatusOK
+	if summary.Color == healthRed {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, summary)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HistoryEntry ist eine versionierte Fassung einer Entität
+type HistoryEntry struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Entity    BaseDto   `json:"entity"`
+}
+
+// historyStore hält alle geschriebenen Versionen einer Entität im Speicher.
+// Schreibvorgänge hängen eine neue Version an, statt die alte zu überschreiben.
+type historyStore struct {
+	mu       sync.RWMutex
+	versions map[string][]HistoryEntry
+}
+
+var history = &historyStore{versions: map[string][]HistoryEntry{}}
+
+func (h *historyStore) append(dto BaseDto) HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry := HistoryEntry{
+		Version:   len(h.versions[dto.ID]) + 1,
+		Timestamp: time.Now().UTC(),
+		Entity:    dto,
+	}
+	h.versions[dto.ID] = append(h.versions[dto.ID], entry)
+	return entry
+}
+
+func (h *historyStore) get(id string) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return append([]HistoryEntry(nil), h.versions[id]...)
+}
+
+// recordHistory legt eine neue Version an, wenn KEEP_HISTORY aktiv ist
+func recordHistory(ctx context.Context, dto BaseDto) {
+	if !config.KeepHistory {
+		return
+	}
+	entry := history.append(dto)
+	slog.InfoContext(ctx, "Stored entity version", "id", dto.ID, "version", entry.Version)
+}
+
+func getHistory(c *gin.Context) {
+	id := c.Param("id")
+	slog.DebugContext(c.Request.Context(), "Entered GET /api/base/:id/history", "id", id)
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+
+	versions := history.get(id)
+	time.Sleep(currentResponseDelay())
+	if len(versions) == 0 {
+		respondError(c, http.StatusNotFound, "no history for entity "+id)
+		return
+	}
+	if !canAccess(callerIdentity(c), versions[len(versions)-1].Entity) {
+		respondError(c, http.StatusForbidden, "access to entity "+id+" denied")
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// http10Middleware beantwortet Requests wie ein Legacy-Service mit HTTP/1.0:
+// Die Antwort wird gepuffert und direkt auf die übernommene Verbindung
+// geschrieben, mit explizitem Content-Length und ohne Keep-Alive. Die
+// Verbindung wird danach geschlossen.
+func http10Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := newBufferedResponseWriter(c.Writer)
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		conn, buf, err := writer.ResponseWriter.Hijack()
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Konnte Verbindung nicht für HTTP/1.0 übernehmen", "error", err)
+			c.Header("Connection", "close")
+			writer.flush()
+			return
+		}
+		defer conn.Close()
+
+		status := writer.ResponseWriter.Status()
+		header := writer.ResponseWriter.Header().Clone()
+		header.Del("Transfer-Encoding")
+		header.Set("Connection", "close")
+		header.Set("Content-Length", strconv.Itoa(writer.body.Len()))
+		header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+		fmt.Fprintf(buf, "HTTP/1.0 %d %s\r\n", status, http.StatusText(status))
+		header.Write(buf)
+		buf.WriteString("\r\n")
+		if c.Request.Method != http.MethodHead {
+			buf.Write(writer.body.Bytes())
+		}
+		if err := buf.Flush(); err != nil {
+			slog.WarnContext(c.Request.Context(), "Konnte HTTP/1.0-Antwort nicht schreiben", "error", err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// idempotencyWaitTimeout begrenzt, wie lange ein wiederholter Request auf
+	// das Ergebnis des noch laufenden ersten Requests wartet
+	idempotencyWaitTimeout  = 5 * time.Second
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// idempotencyRecord ist das gespeicherte Ergebnis eines create-Requests. Ohne
+// Status ist der Key nur reserviert, der erste Request läuft noch.
+type idempotencyRecord struct {
+	Status  int
+	Body    BaseDto
+	Expires time.Time
+}
+
+func (r idempotencyRecord) inProgress() bool {
+	return r.Status == 0
+}
+
+// idempotencyStore speichert Ergebnisse von create-Requests je Idempotency-Key.
+// reserve legt den Key atomar als "in Bearbeitung" an, sofern er noch nicht
+// existiert, und liefert sonst den vorhandenen Eintrag.
+type idempotencyStore interface {
+	get(key string) (idempotencyRecord, bool)
+	reserve(key string, expires time.Time) (idempotencyRecord, bool, error)
+	put(key string, record idempotencyRecord)
+	remove(key string)
+}
+
+// memoryIdempotencyStore hält die Ergebnisse im Speicher, abgelaufene Einträge
+// werden periodisch entfernt
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotencyRecord
+}
+
+func newMemoryIdempotencyStore(ttl time.Duration) *memoryIdempotencyStore {
+	store := &memoryIdempotencyStore{records: map[string]idempotencyRecord{}}
+	go func() {
+		for range time.Tick(ttl) {
+			store.prune()
+		}
+	}()
+	return store
+}
+
+func (s *memoryIdempotencyStore) get(key string) (idempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || time.Now().After(record.Expires) {
+		return idempotencyRecord{}, false
+	}
+	return record, true
+}
+
+func (s *memoryIdempotencyStore) reserve(key string, expires time.Time) (idempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && time.Now().Before(record.Expires) {
+		return record, false, nil
+	}
+	s.records[key] = idempotencyRecord{Expires: expires}
+	return idempotencyRecord{}, true, nil
+}
+
+func (s *memoryIdempotencyStore) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+}
+
+func (s *memoryIdempotencyStore) put(key string, record idempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record
+}
+
+func (s *memoryIdempotencyStore) prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, record := range s.records {
+		if now.After(record.Expires) {
+			delete(s.records, key)
+		}
+	}
+}
+
+var idempotencyKeys idempotencyStore
+
+const (
+	idempotencyStoreMemory = "memory"
+	idempotencyStoreDB     = "db"
+)
+
+// initIdempotencyStore wählt den Store für Idempotency-Keys. Es muss nach
+// initDB aufgerufen werden, damit die Datenbank-Stores eine Verbindung vorfinden.
+func initIdempotencyStore() {
+	if config.IdempotencyTTL <= 0 {
+		return
+	}
+
+	// Redis wird bevorzugt, damit es auch neben SQL oder MongoDB allein die
+	// Idempotency-Keys halten kann
+	if config.IdempotencyStore == idempotencyStoreDB {
+		switch {
+		case config.Redis.Addr != "":
+			idempotencyKeys = redisIdempotencyStore{client: newRedisClient()}
+			return
+		case config.Datasource.Host != "":
+			store := sqlIdempotencyStore{}
+			go func() {
+				for range time.Tick(config.IdempotencyTTL) {
+					if dbConnected.Load() {
+						store.prune()
+					}
+				}
+			}()
+			idempotencyKeys = store
+			prepareConnectedIdempotencyStore()
+			return
+		case config.MongoDB.Host != "":
+			idempotencyKeys = mongoIdempotencyStore{}
+			prepareConnectedIdempotencyStore()
+			return
+		}
+		slog.Warn("IdempotencyStore=db ohne konfigurierte Datenbank, verwende memory")
+	}
+	idempotencyKeys = newMemoryIdempotencyStore(config.IdempotencyTTL)
+}
+
+// prepareConnectedIdempotencyStore legt Tabelle bzw. Index an, falls die
+// Datenbank bereits verbunden ist. Andernfalls übernimmt das prepareDB, sobald
+// die Verbindung steht.
+func prepareConnectedIdempotencyStore() {
+	if !dbConnected.Load() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+	prepareIdempotencyStore(ctx)
+}
+
+// usesPersistentIdempotencyStore liefert true, wenn die Keys in der SQL-Datenbank
+// oder MongoDB liegen und dort Tabelle bzw. Index angelegt werden müssen
+func usesPersistentIdempotencyStore() bool {
+	switch idempotencyKeys.(type) {
+	case sqlIdempotencyStore, mongoIdempotencyStore:
+		return true
+	}
+	return false
+}
+
+// scopedIdempotencyKey ordnet key dem Aufrufer zu, damit verschiedene
+// API-Keys sich nicht gegenseitig die Ergebnisse ihrer Requests wiederholen
+func scopedIdempotencyKey(identity, key string) string {
+	if key == "" {
+		return ""
+	}
+	return identity + ":" + key
+}
+
+// reserveIdempotency reserviert key für einen create-Request. Ist key bereits
+// abgeschlossen, wird das gespeicherte Ergebnis mit replayed=true geliefert.
+// Läuft der erste Request noch, wird bis idempotencyWaitTimeout auf dessen
+// Ergebnis gewartet und danach mit 409 abgebrochen. Ist der Store nicht
+// erreichbar, wird mit 503 abgebrochen, statt den Request ohne Schutz vor
+// Wiederholungen auszuführen. Ohne Store oder Key gibt es nichts zu
+// reservieren.
+func reserveIdempotency(ctx context.Context, key string) (idempotencyRecord, bool, error) {
+	if idempotencyKeys == nil || key == "" {
+		return idempotencyRecord{}, false, nil
+	}
+
+	inProgress := withStatus(http.StatusConflict, fmt.Errorf("request with idempotency key %s is still in progress", key))
+	deadline := time.Now().Add(idempotencyWaitTimeout)
+	for {
+		record, reserved, err := idempotencyKeys.reserve(key, time.Now().Add(config.IdempotencyTTL))
+		if err != nil {
+			slog.WarnContext(ctx, "Konnte Idempotency-Key nicht reservieren", "key", key, "error", err)
+			return idempotencyRecord{}, false, withStatus(http.StatusServiceUnavailable, fmt.Errorf("idempotency store unavailable: %w", err))
+		}
+		if reserved {
+			return idempotencyRecord{}, false, nil
+		}
+		if !record.inProgress() {
+			slog.InfoContext(ctx, "Replaying result for idempotency key", "key", key)
+			return record, true, nil
+		}
+		if time.Now().After(deadline) {
+			slog.WarnContext(ctx, "Idempotency-Key wird noch bearbeitet", "key", key)
+			return idempotencyRecord{}, false, inProgress
+		}
+
+		select {
+		case <-ctx.Done():
+			return idempotencyRecord{}, false, inProgress
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// releaseIdempotency gibt eine Reservierung wieder frei, wenn der Request
+// ohne gespeichertes Ergebnis endet, damit ein erneuter Versuch möglich ist
+func releaseIdempotency(key string) {
+	if idempotencyKeys != nil && key != "" {
+		idempotencyKeys.remove(key)
+	}
+}
+
+// completeIdempotency merkt sich das Ergebnis eines erfolgreichen create-Requests
+func completeIdempotency(key string, dto BaseDto) {
+	if idempotencyKeys == nil || key == "" {
+		return
+	}
+	idempotencyKeys.put(key, idempotencyRecord{
+		Status:  http.StatusCreated,
+		Body:    dto,
+		Expires: time.Now().Add(config.IdempotencyTTL),
+	})
+}
+
+// reserveIdempotencyKey reserviert den Idempotency-Key des Requests und
+// beantwortet wiederholte Requests. Liefert true, wenn der Request damit
+// erledigt ist.
+func reserveIdempotencyKey(c *gin.Context) bool {
+	key := scopedIdempotencyKey(callerIdentity(c), c.GetHeader(idempotencyKeyHeader))
+	record, replayed, err := reserveIdempotency(c.Request.Context(), key)
+	switch {
+	case err != nil:
+		respondError(c, errorStatus(err), err.Error())
+		return true
+	case replayed:
+		c.Header(idempotencyReplayedHeader, "true")
+		respondNegotiated(c, record.Status, record.Body)
+		return true
+	}
+	c.Set("idempotencyKey", key)
+	return false
+}
+
+// releaseIdempotencyKey gibt die Reservierung des Requests frei, sofern kein
+// Ergebnis gespeichert wurde
+func releaseIdempotencyKey(c *gin.Context) {
+	releaseIdempotency(c.GetString("idempotencyKey"))
+}
+
+// storeIdempotentResult merkt sich das Ergebnis des Requests
+func storeIdempotentResult(c *gin.Context, dto BaseDto) {
+	completeIdempotency(c.GetString("idempotencyKey"), dto)
+	c.Set("idempotencyKey", "")
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	idempotencyCollection  = "idempotency_keys"
+	redisIdempotencyPrefix = "idempotency:"
+)
+
+// sqlIdempotencyStore speichert Idempotency-Keys in der SQL-Datenbank, damit
+// sie einen Neustart des Service überdauern
+type sqlIdempotencyStore struct{}
+
+func (sqlIdempotencyStore) get(key string) (idempotencyRecord, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var record idempotencyRecord
+	var body string
+	err := currentSQLDB().QueryRowContext(ctx,
+		rebind("SELECT status, body, expires FROM idempotency_keys WHERE "+quoteIdent("key")+" = $1 AND expires > $2"), key, time.Now()).
+		Scan(&record.Status, &body, &record.Expires)
+	if err == nil {
+		err = json.Unmarshal([]byte(body), &record.Body)
+	}
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("Konnte Idempotency-Key nicht lesen", "key", key, "error", err)
+		}
+		return idempotencyRecord{}, false
+	}
+	return record, true
+}
+
+// reserve löscht einen abgelaufenen Eintrag und legt den Key danach ohne
+// Status an. Ob das INSERT gegriffen hat, entscheidet der Primärschlüssel.
+func (s sqlIdempotencyStore) reserve(key string, expires time.Time) (idempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	db := currentSQLDB()
+	_, err := db.ExecContext(ctx, rebind("DELETE FROM idempotency_keys WHERE "+quoteIdent("key")+" = $1 AND expires <= $2"), key, time.Now())
+	var result sql.Result
+	if err == nil {
+		result, err = db.ExecContext(ctx, rebind("INSERT INTO idempotency_keys ("+quoteIdent("key")+", status, body, expires) VALUES ($1, 0, '{}', $2) "+
+			ignoreConflictClause(quoteIdent("key"))), key, expires)
+	}
+	var inserted int64
+	if err == nil {
+		inserted, err = result.RowsAffected()
+	}
+	if err != nil {
+		return idempotencyRecord{}, false, err
+	}
+	if inserted > 0 {
+		return idempotencyRecord{}, true, nil
+	}
+	record, _ := s.get(key)
+	return record, false, nil
+}
+
+func (sqlIdempotencyStore) remove(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if _, err := currentSQLDB().ExecContext(ctx, rebind("DELETE FROM idempotency_keys WHERE "+quoteIdent("key")+" = $1"), key); err != nil {
+		slog.Warn("Konnte Idempotency-Key nicht freigeben", "key", key, "error", err)
+	}
+}
+
+func (sqlIdempotencyStore) put(key string, record idempotencyRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(record.Body)
+	if err == nil {
+		_, err = currentSQLDB().ExecContext(ctx, rebind("INSERT INTO idempotency_keys ("+quoteIdent("key")+", status, body, expires) VALUES ($1, $2, $3, $4) "+
+			upsertClause(quoteIdent("key"), "status", "body", "expires")),
+			key, record.Status, string(body), record.Expires)
+	}
+	if err != nil {
+		slog.Warn("Konnte Idempotency-Key nicht speichern", "key", key, "error", err)
+	}
+}
+
+func (sqlIdempotencyStore) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if _, err := currentSQLDB().ExecContext(ctx, rebind("DELETE FROM idempotency_keys WHERE expires <= $1"), time.Now()); err != nil {
+		slog.Warn("Konnte abgelaufene Idempotency-Keys nicht löschen", "error", err)
+	}
+}
+
+// mongoIdempotencyRecord ist die Dokumentform eines Idempotency-Keys; abgelaufene
+// Dokumente entfernt MongoDB über einen TTL-Index selbst
+type mongoIdempotencyRecord struct {
+	Key     string    `bson:"_id"`
+	Status  int       `bson:"status"`
+	Body    BaseDto   `bson:"body"`
+	Expires time.Time `bson:"expires"`
+}
+
+type mongoIdempotencyStore struct{}
+
+func (mongoIdempotencyStore) collection() *mongo.Collection {
+	dbLock.RLock()
+	defer dbLock.RUnlock()
+	return mongoClient.Database(config.MongoDB.DBName).Collection(idempotencyCollection)
+}
+
+func (s mongoIdempotencyStore) get(key string) (idempotencyRecord, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var doc mongoIdempotencyRecord
+	err := s.collection().FindOne(ctx, bson.M{"_id": key, "expires": bson.M{"$gt": time.Now()}}).Decode(&doc)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			slog.Warn("Konnte Idempotency-Key nicht lesen", "key", key, "error", err)
+		}
+		return idempotencyRecord{}, false
+	}
+	return idempotencyRecord{Status: doc.Status, Body: doc.Body, Expires: doc.Expires}, true
+}
+
+// reserve legt den Key als Dokument ohne Status an. Abgelaufene Dokumente
+// entfernt der TTL-Index nur verzögert, daher werden sie vorher gelöscht.
+func (s mongoIdempotencyStore) reserve(key string, expires time.Time) (idempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	collection := s.collection()
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key, "expires": bson.M{"$lte": time.Now()}})
+	if err == nil {
+		_, err = collection.InsertOne(ctx, mongoIdempotencyRecord{Key: key, Expires: expires})
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		record, _ := s.get(key)
+		return record, false, nil
+	}
+	if err != nil {
+		return idempotencyRecord{}, false, err
+	}
+	return idempotencyRecord{}, true, nil
+}
+
+func (s mongoIdempotencyStore) remove(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if _, err := s.collection().DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		slog.Warn("Konnte Idempotency-Key nicht freigeben", "key", key, "error", err)
+	}
+}
+
+func (s mongoIdempotencyStore) put(key string, record idempotencyRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	doc := mongoIdempotencyRecord{Key: key, Status: record.Status, Body: record.Body, Expires: record.Expires}
+	if _, err := s.collection().ReplaceOne(ctx, bson.M{"_id": key}, doc, options.Replace().SetUpsert(true)); err != nil {
+		slog.Warn("Konnte Idempotency-Key nicht speichern", "key", key, "error", err)
+	}
+}
+
+// redisIdempotencyStore speichert Idempotency-Keys in Redis. Die Einträge
+// laufen über die TTL des Schlüssels ab, ein Aufräumen ist nicht nötig.
+type redisIdempotencyStore struct {
+	client *redis.Client
+}
+
+func redisIdempotencyKey(key string) string {
+	return redisIdempotencyPrefix + key
+}
+
+func (s redisIdempotencyStore) get(key string) (idempotencyRecord, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var record idempotencyRecord
+	data, err := s.client.Get(ctx, redisIdempotencyKey(key)).Result()
+	if err == nil {
+		err = json.Unmarshal([]byte(data), &record)
+	}
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Warn("Konnte Idempotency-Key nicht lesen", "key", key, "error", err)
+		}
+		return idempotencyRecord{}, false
+	}
+	return record, true
+}
+
+// reserve legt den Key mit SETNX an, sodass nur der erste Request ihn erhält
+func (s redisIdempotencyStore) reserve(key string, expires time.Time) (idempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(idempotencyRecord{Expires: expires})
+	var reserved bool
+	if err == nil {
+		reserved, err = s.client.SetNX(ctx, redisIdempotencyKey(key), data, time.Until(expires)).Result()
+	}
+	if err != nil {
+		return idempotencyRecord{}, false, err
+	}
+	if reserved {
+		return idempotencyRecord{}, true, nil
+	}
+	record, _ := s.get(key)
+	return record, false, nil
+}
+
+func (s redisIdempotencyStore) put(key string, record idempotencyRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(record)
+	if err == nil {
+		err = s.client.Set(ctx, redisIdempotencyKey(key), data, time.Until(record.Expires)).Err()
+	}
+	if err != nil {
+		slog.Warn("Konnte Idempotency-Key nicht speichern", "key", key, "error", err)
+	}
+}
+
+func (s redisIdempotencyStore) remove(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if err := s.client.Del(ctx, redisIdempotencyKey(key)).Err(); err != nil {
+		slog.Warn("Konnte Idempotency-Key nicht freigeben", "key", key, "error", err)
+	}
+}
+
+// prepareIdempotencyStore legt Tabelle bzw. TTL-Index für persistente
+// Idempotency-Keys an
+func prepareIdempotencyStore(ctx context.Context) {
+	if db := currentSQLDB(); db != nil {
+		_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS idempotency_keys (
+			`+quoteIdent("key")+` VARCHAR(255) PRIMARY KEY,
+			status INTEGER,
+			body `+textType()+`,
+			expires `+timestampType()+`
+		)`)
+		if err != nil {
+			slog.Warn("Konnte Tabelle idempotency_keys nicht anlegen", "error", err)
+		}
+		return
+	}
+
+	_, err := mongoIdempotencyStore{}.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		slog.Warn("Konnte TTL-Index für Idempotency-Keys nicht anlegen", "error", err)
+	}
+}
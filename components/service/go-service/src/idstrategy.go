@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	idStrategyUUID       = "uuid"
+	idStrategySequential = "sequential"
+)
+
+// nextSequentialID zählt die vergebenen IDs der Strategie sequential. Die
+// Zählung beginnt hinter den generierten Dummy-Entitäten go-1 bis go-<EntityCount>.
+var nextSequentialID atomic.Int64
+
+// parseIDStrategy liest die Strategie für serverseitig vergebene IDs
+func parseIDStrategy(strategyStr string) string {
+	switch strategy := strings.ToLower(strings.TrimSpace(strategyStr)); strategy {
+	case "", idStrategyUUID:
+		return idStrategyUUID
+	case idStrategySequential:
+		return strategy
+	default:
+		slog.Warn("Unbekannte IDStrategy, verwende Default", "idStrategy", strategyStr, "default", idStrategyUUID)
+		return idStrategyUUID
+	}
+}
+
+// assignID vergibt eine ID, wenn der Client keine mitgeschickt hat
+func assignID(dto *BaseDto) {
+	if strings.TrimSpace(dto.ID) != "" {
+		return
+	}
+	if config.IDStrategy == idStrategySequential {
+		nextSequentialID.CompareAndSwap(0, int64(config.EntityCount))
+		dto.ID = fmt.Sprintf("go-%d", nextSequentialID.Add(1))
+		return
+	}
+	dto.ID = newUUID()
+}
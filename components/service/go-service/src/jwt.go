@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type jwtSubjectKey struct{}
+
+// jwtHeader und jwtClaims enthalten die geprüften Felder eines Tokens
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// jwtMiddleware verlangt für jeden Request ein gültiges HS256-Bearer-Token,
+// signiert mit JWTSecret. Der Subject des Tokens wird im Kontext abgelegt und
+// in jeder Log-Zeile des Requests ausgegeben.
+func jwtMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			c.Header("WWW-Authenticate", `Bearer realm="microzoo"`)
+			abortWithError(c, http.StatusUnauthorized, "bearer token required")
+			return
+		}
+		claims, err := verifyJWT(strings.TrimSpace(token), time.Now())
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Token abgelehnt", "error", err)
+			c.Header("WWW-Authenticate", `Bearer realm="microzoo", error="invalid_token"`)
+			abortWithError(c, http.StatusUnauthorized, "invalid token: "+err.Error())
+			return
+		}
+
+		c.Set("subject", claims.Subject)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), jwtSubjectKey{}, claims.Subject))
+		c.Next()
+	}
+}
+
+// verifyJWT prüft Algorithmus, Signatur sowie exp und nbf des Tokens
+func verifyJWT(token string, now time.Time) (jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtClaims{}, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return jwtClaims{}, err
+	}
+	if header.Alg != "HS256" {
+		return jwtClaims{}, errors.New("unsupported algorithm " + header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return jwtClaims{}, errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return jwtClaims{}, errors.New("signature mismatch")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return jwtClaims{}, err
+	}
+	if claims.ExpiresAt != nil && !now.Before(time.Unix(*claims.ExpiresAt, 0)) {
+		return jwtClaims{}, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0)) {
+		return jwtClaims{}, errors.New("token not yet valid")
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed token")
+	}
+	return nil
+}
+
+func jwtSubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(jwtSubjectKey{}).(string)
+	return subject
+}
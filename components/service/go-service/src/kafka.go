@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	kafkaFailModeBestEffort = "best-effort"
+	kafkaFailModeFatal      = "fatal"
+)
+
+// kafkaBatchTimeout begrenzt, wie lange der Producer auf weitere Nachrichten
+// für einen Batch wartet. Der Default von einer Sekunde würde jeden POST um
+// diese Zeit verzögern.
+const kafkaBatchTimeout = 5 * time.Millisecond
+
+// kafkaWriter ist nil, solange keine Broker konfiguriert sind
+var kafkaWriter *kafka.Writer
+
+// initKafkaProducer richtet den Producer für angelegte Entitäten ein. Die
+// Nachrichten werden nach ID partitioniert, sodass alle Ereignisse einer
+// Entität in derselben Partition landen. Im Modus best-effort wird asynchron
+// gesendet und Fehler werden nur noch geloggt, im Modus fatal wartet der
+// Request auf die Bestätigung.
+func initKafkaProducer() {
+	if len(config.KafkaBrokers) == 0 || config.KafkaTopic == "" {
+		return
+	}
+	kafkaWriter = &kafka.Writer{
+		Addr:         kafka.TCP(config.KafkaBrokers...),
+		Topic:        config.KafkaTopic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: kafkaBatchTimeout,
+	}
+	if config.KafkaFailMode != kafkaFailModeFatal {
+		kafkaWriter.Async = true
+		kafkaWriter.Completion = func(messages []kafka.Message, err error) {
+			if err == nil {
+				return
+			}
+			for _, message := range messages {
+				slog.Error("Konnte Entität nicht an Kafka senden",
+					"id", string(message.Key), "topic", config.KafkaTopic, "failMode", config.KafkaFailMode, "error", err)
+			}
+		}
+	}
+	slog.Info("Publishing created entities to Kafka", "brokers", config.KafkaBrokers, "topic", config.KafkaTopic)
+}
+
+// publishCreated veröffentlicht die angelegte Entität als JSON. Im Modus fatal
+// wird der Fehler zurückgegeben und der Request scheitert mit 500, im Modus
+// best-effort wird er nur geloggt.
+func publishCreated(ctx context.Context, dto BaseDto) error {
+	if kafkaWriter == nil {
+		return nil
+	}
+	err := publishEntity(ctx, dto)
+	if err == nil {
+		return nil
+	}
+	slog.ErrorContext(ctx, "Konnte Entität nicht an Kafka senden",
+		"id", dto.ID, "topic", config.KafkaTopic, "failMode", config.KafkaFailMode, "error", err)
+	if config.KafkaFailMode != kafkaFailModeFatal {
+		return nil
+	}
+	return fmt.Errorf("publishing entity %s failed: %w", dto.ID, err)
+}
+
+func publishEntity(ctx context.Context, dto BaseDto) error {
+	value, err := json.Marshal(dto)
+	if err != nil {
+		return err
+	}
+	return kafkaWriter.WriteMessages(ctx, kafka.Message{Key: []byte(dto.ID), Value: value})
+}
+
+// closeKafka schließt den Producer beim Herunterfahren und sendet dabei noch
+// gepufferte Nachrichten
+func closeKafka() {
+	if kafkaWriter == nil {
+		return
+	}
+	if err := kafkaWriter.Close(); err != nil {
+		slog.Warn("Konnte Kafka-Producer nicht schließen", "error", err)
+		return
+	}
+	slog.Info("Closed Kafka producer")
+}
+
+var (
+	kafkaReader       *kafka.Reader
+	stopKafkaConsumer = func() {}
+)
+
+// startKafkaConsumer liest Entitäten aus KAFKA_CONSUME_TOPIC und speichert sie
+// in der Datenbank, sodass der Service als Senke einer Event-Pipeline dient
+func startKafkaConsumer() {
+	if len(config.KafkaBrokers) == 0 || config.KafkaConsumeTopic == "" {
+		return
+	}
+	kafkaReader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers: config.KafkaBrokers,
+		Topic:   config.KafkaConsumeTopic,
+		GroupID: config.KafkaConsumerGroup,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	stopKafkaConsumer = func() {
+		cancel()
+		<-done
+		if err := kafkaReader.Close(); err != nil {
+			slog.Warn("Konnte Kafka-Consumer nicht schließen", "error", err)
+			return
+		}
+		slog.Info("Closed Kafka consumer")
+	}
+
+	slog.Info("Consuming entities from Kafka", "brokers", config.KafkaBrokers,
+		"topic", config.KafkaConsumeTopic, "group", config.KafkaConsumerGroup)
+	go func() {
+		defer close(done)
+		for {
+			message, err := kafkaReader.ReadMessage(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("Konnte Nachricht nicht von Kafka lesen", "topic", config.KafkaConsumeTopic, "error", err)
+				}
+				return
+			}
+			consumeEntity(ctx, message)
+		}
+	}()
+}
+
+func consumeEntity(ctx context.Context, message kafka.Message) {
+	var dto BaseDto
+	if err := json.Unmarshal(message.Value, &dto); err != nil {
+		slog.Warn("Ungültige Nachricht wird übersprungen", "topic", message.Topic,
+			"partition", message.Partition, "offset", message.Offset, "error", err)
+		return
+	}
+	if !isDBActive() {
+		slog.Warn("Keine Datenbank konfiguriert, Entität wird verworfen", "id", dto.ID)
+		return
+	}
+	if _, err := saveToDB(ctx, dto); err != nil {
+		slog.Error("Konnte Entität aus Kafka nicht speichern", "id", dto.ID, "error", err)
+		return
+	}
+	listCache.invalidate()
+	slog.Debug("Saved entity from Kafka", "id", dto.ID, "partition", message.Partition, "offset", message.Offset)
+}
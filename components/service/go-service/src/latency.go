@@ -0,0 +1,16 @@
+package main
+
+import "time"
+
+// stampFetchDuration hinterlegt an jeder Entität die Zeit, die das Erzeugen
+// bzw. Abrufen ihres Batches gedauert hat (Datenbankabfrage, Upstream-Aufruf
+// oder Generierung). Aktiv nur mit EntityLatencyMetadata.
+func stampFetchDuration(dtos []BaseDto, elapsed time.Duration) {
+	if !config.EntityLatencyMetadata {
+		return
+	}
+	ms := float64(elapsed.Microseconds()) / 1000
+	for i := range dtos {
+		dtos[i].FetchDurationMs = &ms
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+// apiLimits sind die Limiter für /api/base. HTTP und gRPC teilen sich
+// dieselben Instanzen, sodass ein Client über beide Transporte gemeinsam
+// begrenzt wird. Nicht konfigurierte Limiter bleiben nil.
+type apiLimits struct {
+	rateLimiter *rateLimiter
+	scheduler   *fairScheduler
+	methods     *methodLimiter
+}
+
+func newAPILimits() apiLimits {
+	var limits apiLimits
+	if config.RateLimitRPS > 0 {
+		limits.rateLimiter = newRateLimiter()
+	}
+	if config.MaxConcurrentRequests > 0 {
+		limits.scheduler = newFairScheduler(config.MaxConcurrentRequests)
+	}
+	if config.MaxConcurrentReads > 0 || config.MaxConcurrentWrites > 0 {
+		limits.methods = newMethodLimiter()
+	}
+	return limits
+}
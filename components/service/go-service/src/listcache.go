@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// listResponseCache hält die aus der Datenbank gelesenen Listen für CacheTTL
+// vor. Schlüssel ist die effektive Abfrage; jede Änderung leert den Cache.
+type listResponseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedList
+}
+
+type cachedList struct {
+	dtos    []BaseDto
+	expires time.Time
+}
+
+var listCache = &listResponseCache{entries: map[string]cachedList{}}
+
+// listCacheKey bildet den Schlüssel aus den Parametern, die das Ergebnis der
+// Datenbankabfrage bestimmen
+func listCacheKey(lastID string, paged bool, since time.Time, incremental bool, limit, offset int) string {
+	switch {
+	case paged:
+		return "cursor=" + lastID
+	case incremental:
+		return "updatedSince=" + since.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("limit=%d&offset=%d", limit, offset)
+}
+
+// get liefert eine Kopie der gecachten Liste, da die Aufrufer die Entitäten
+// vor der Auslieferung verändern
+func (l *listResponseCache) get(key string) ([]BaseDto, bool) {
+	if config.CacheTTL <= 0 {
+		return nil, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(l.entries, key)
+		return nil, false
+	}
+	return slices.Clone(entry.dtos), true
+}
+
+func (l *listResponseCache) put(key string, dtos []BaseDto) {
+	if config.CacheTTL <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[key] = cachedList{dtos: slices.Clone(dtos), expires: time.Now().Add(config.CacheTTL)}
+}
+
+// invalidate leert den Cache nach einer Änderung der Daten
+func (l *listResponseCache) invalidate() {
+	if config.CacheTTL <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.entries)
+}
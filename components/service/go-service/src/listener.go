@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+
+	"golang.org/x/net/netutil"
+)
+
+// newListener öffnet den TCP-Listener. Mit MaxConnections werden höchstens so
+// viele Verbindungen gleichzeitig angenommen, weitere warten im Accept-Backlog
+// des Betriebssystems.
+func newListener(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxConnections > 0 {
+		slog.Info("Limiting listener", "maxConnections", config.MaxConnections)
+		listener = netutil.LimitListener(listener, config.MaxConnections)
+	}
+	return listener, nil
+}
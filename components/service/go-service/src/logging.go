@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// initLogger stellt das Logging auf JSON mit Level um. Ausgaben von
+// Bibliotheken über das log-Paket laufen danach über diesen Logger (mit Level INFO).
+func initLogger() {
+	handler := requestIDHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: config.LogLevel})}
+	slog.SetDefault(slog.New(handler).With("service", "go-service"))
+}
+
+// parseLogLevel liest ein Log-Level wie "debug", "info", "warn" oder "error"
+func parseLogLevel(levelStr string) slog.Level {
+	var level slog.Level
+	if levelStr == "" {
+		return slog.LevelInfo
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(levelStr))); err != nil {
+		slog.Warn("Unbekanntes Log-Level, verwende info", "logLevel", levelStr)
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// requestLogMiddleware protokolliert jeden Request nach seiner Bearbeitung
+// über slog, sodass auch diese Zeilen die Request-ID tragen
+func requestLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		slog.InfoContext(c.Request.Context(), "Handled request",
+			"method", c.Request.Method, "path", c.Request.URL.Path, "status", c.Writer.Status(),
+			"latency", time.Since(start).String(), "clientIp", c.ClientIP(), "bytes", c.Writer.Size())
+	}
+}
@@ -1,215 +1,1013 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mitchellh/mapstructure"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
 )
 
 // MicrozooConfigProperties entspricht der Konfiguration aus der Java-Anwendung
 type MicrozooConfigProperties struct {
-	RequestDelay   time.Duration
-	ResponseDelay  time.Duration
+	RequestDelay     time.Duration
+	ResponseDelay    time.Duration
 	UpstreamServices []string
-	EntityCount    int
-	PayloadSize    int
+	EntityCount      int `mapstructure:"-"`
+	PayloadSize      int
+	HmacSecret       string
+
+	// Begrenzung gleichzeitig bearbeiteter Requests (0 = unbegrenzt)
+	MaxConcurrentRequests int
+	AdmissionMode         string
+
+	// Datei, aus der Delays zur Laufzeit gelesen werden
+	DelayFile string
+
+	// Versionshistorie aller geschriebenen Entitäten
+	KeepHistory bool `mapstructure:"keep_history"`
+
+	// Query-Parameter-basierte Auswahl der Upstream-Services
+	UpstreamRoutes []UpstreamRoute `mapstructure:"-"`
+
+	// Verzögertes Aufwärmen nach dem Start
+	StartupDelay   time.Duration
+	WarmupRequests int
+
+	// Kompression aggregierter Upstream-Antworten
+	CompressAggregation bool
+
+	// Filterung von Entitäten anhand des owner-Felds und des API-Keys
+	AccessControl bool
+
+	// Aufbewahrungsdauer (0 = deaktiviert) und Speicherort (memory|db) für Idempotency-Keys
+	IdempotencyTTL   time.Duration
+	IdempotencyStore string
+
+	// Format von Fehlerantworten (envelope|problem)
+	ErrorFormat string
+
+	// Datenbankanbindung (PostgreSQL, MySQL, MongoDB oder Redis)
+	Datasource            DatasourceConfig
+	MongoDB               MongoDBConfig
+	DBHealthCheckInterval time.Duration
+	DBReconnectMaxBackoff time.Duration
+
+	// Maximales Alter gecachter Entitäten, die bei DB-Fehlern ausgeliefert werden (0 = deaktiviert)
+	StaleIfError time.Duration
+
+	// Gewichtete Statuscodes je Endpunkt
+	ErrorProfiles map[string][]weightedStatus `mapstructure:"-"`
+
+	// Seitengröße der Cursor-Paginierung (0 = deaktiviert)
+	CursorPageSize int
+
+	// Umbenennung von Feldern in Antworten einzelner Upstreams
+	UpstreamFieldMappings map[string]map[string]string `mapstructure:"-"`
+
+	// Version der Instanz für Blue/Green- und Canary-Szenarien
+	Version string
+
+	// Antworten als HTTP/1.0 ohne Keep-Alive ausliefern
+	HTTP10 bool
+
+	// Simulation langsamer Festplatten (Bytes pro Sekunde und feste Zugriffszeit)
+	DiskThroughput  int
+	DiskSeekLatency time.Duration
+
+	// Backup-Upstream je primärem Upstream (aktiv/passiv)
+	UpstreamBackups map[string]string `mapstructure:"-"`
+
+	// Access-Log in eine rotierende Datei
+	AccessLogFile       string
+	AccessLogMaxSizeMB  int
+	AccessLogMaxBackups int
+
+	// Felder, die getAll pro Entität berechnet (payloadLength, checksum)
+	ComputedFields []string `mapstructure:"-"`
+
+	// Prozessweite Bandbreitenbegrenzung für Antworten, 0 = unbegrenzt
+	GlobalBandwidthBytesPerSec int
+
+	// Deterministische Statussequenzen pro Client
+	StatusSequences map[string][]int `mapstructure:"-"`
+
+	// OTLP-Endpunkt (host:port) des OpenTelemetry-Collectors
+	OtelEndpoint string
+
+	// Transformation der Entität vor der Weitergabe an Upstream-Services
+	RequestTransform RequestTransform `mapstructure:"-"`
+
+	// Verhalten beim Herunterfahren: drain-serving oder drain-rejecting
+	ShutdownMode  string
+	ShutdownDelay time.Duration
+
+	// Payloads oberhalb dieser Größe werden pro Entität komprimiert, 0 = aus
+	PayloadCompressionThreshold int
+
+	// Tägliche Wartungsfenster (UTC), in denen /api/base mit 503 antwortet
+	DowntimeWindows []downtimeWindow `mapstructure:"-"`
+
+	// Reihenfolge der JSON-Felder von BaseDto für Clients mit striktem Parser
+	FieldOrder []string `mapstructure:"-"`
+
+	// Anteil der Upstream-Aufrufe, deren Namensauflösung fehlschlägt (0.0 - 1.0)
+	DNSFailureRate float64
+
+	// Liefert pro Entität die Dauer ihres Erzeugens bzw. Abrufens mit
+	EntityLatencyMetadata bool
+
+	// Maximale Anzahl gleichzeitig angenommener TCP-Verbindungen, 0 = unbegrenzt
+	MaxConnections int
+
+	// Eigene 404-Antwort für unbekannte Routen, optional mit Liste aller Routen
+	CustomNotFound     bool
+	NotFoundListRoutes bool
+
+	// Größe des Speicher-Ballasts zur Beeinflussung des GC, 0 = kein Ballast
+	BallastBytes int `mapstructure:"ballast_bytes"`
+
+	// Retry-Budget pro Upstream: Größe des Token-Buckets und Auffüllrate pro Sekunde
+	RetryBudgetSize         int
+	RetryBudgetRefillPerSec float64
+
+	// Untergrenze, falls EntityCount als Bereich konfiguriert ist, und die Art,
+	// wie die Anzahl pro Request gewählt wird (random oder request)
+	EntityCountMin  int `mapstructure:"-"`
+	EntityCountMode string
+
+	// Ab dieser Zahl gleichzeitiger Requests werden Payloads um den Faktor gekürzt
+	DegradeConcurrencyThreshold int
+	DegradePayloadFactor        float64
+
+	// Anzahl der Requests im Trace-Puffer unter /actuator/traces, 0 = aus
+	TraceBufferSize int
+
+	// Timeout für die Abfrage der Upstreams in /actuator/health/summary
+	HealthSummaryTimeout time.Duration
+
+	// Künstliche Dauer der Serialisierung pro Entität einer Antwort
+	SerializationDelayPerEntity time.Duration
+
+	// Getrennte Limits für gleichzeitige Lese- und Schreib-Requests (0 = unbegrenzt)
+	MaxConcurrentReads  int
+	MaxConcurrentWrites int
+
+	// Retry-After bei 429/503: Basiswert plus zufälliger Jitter
+	RetryAfterBase   time.Duration
+	RetryAfterJitter time.Duration
+
+	// Hex-kodierter AES-Schlüssel für die Verschlüsselung der Payload beim Speichern
+	EncryptionKey string
+
+	// Timeout des gemeinsamen HTTP-Clients für Upstream-Aufrufe, bei
+	// Überschreitung antworten getAll und create mit 504
+	UpstreamTimeout time.Duration
+
+	// Liefert Warte- und Bearbeitungszeit der Admission im Server-Timing-Header
+	ServerTiming bool
+
+	// Maximale Anzahl paralleler Upstream-Aufrufe in getAll (0 = alle gleichzeitig)
+	UpstreamConcurrency int
+
+	// Erneute Versuche bei Upstream-Fehlern mit exponentiellem Backoff
+	UpstreamRetries      int
+	UpstreamRetryBackoff time.Duration
+
+	// Circuit Breaker pro Upstream: Fehler bis zum Öffnen (0 = aus) und Wartezeit bis zum Probeaufruf
+	BreakerFailureThreshold int
+	BreakerCooldown         time.Duration
+
+	// OTLP-Endpunkt (host:port) für Traces und Anteil der aufgezeichneten Traces (0..1)
+	TracingEndpoint    string
+	TracingSampleRatio float64
+
+	// Minimales Level der strukturierten Log-Ausgabe
+	LogLevel slog.Level `mapstructure:"-"`
+
+	// Maximale Wartezeit auf laufende Requests beim Herunterfahren
+	ShutdownGracePeriod time.Duration
+
+	// SQL-Treiber für die Datasource: postgres oder mysql
+	DBDriver string
+
+	// Redis als Key-Value-Backend
+	Redis RedisConfig
+
+	// Verbindungspool der SQL-Datenbank
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+
+	// Zertifikat und Schlüssel (PEM) für HTTPS; ohne beide bleibt es bei HTTP
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Globales Rate Limit in Requests pro Sekunde (0 = aus), Burst und Limit pro Client
+	RateLimitRPS       float64
+	RateLimitBurst     int
+	RateLimitPerClient bool
+
+	// Lebensdauer gecachter Listen aus der Datenbank (0 = kein Cache)
+	CacheTTL time.Duration
+
+	// Wahrscheinlichkeit (0..1) für zufällige 500er und Seed für reproduzierbare Fehlerfolgen (0 = zufällig)
+	ErrorRate float64
+	ErrorSeed int64
+
+	// Verteilung der Request-/Response-Delays (constant, uniform, normal,
+	// exponential) um RequestDelay bzw. ResponseDelay als Mittelwert. DelaySpread
+	// ist die relative Streuung für uniform (± Anteil) und normal (Standardabweichung).
+	DelayDistribution string
+	DelaySpread       float64
+
+	// CPU-Arbeit pro Request in Millisekunden (0 = aus)
+	CPUWorkMillis int
+
+	// Pro Request belegter Speicher in Bytes (0 = aus)
+	MemoryChurnBytes int
+
+	// Kafka-Broker und Topic für angelegte Entitäten; KafkaFailMode (best-effort
+	// oder fatal) bestimmt, ob ein Sendefehler den Request scheitern lässt
+	KafkaBrokers  []string `mapstructure:"kafka_brokers"`
+	KafkaTopic    string   `mapstructure:"kafka_topic"`
+	KafkaFailMode string
+
+	// Topic, aus dem Entitäten gelesen und gespeichert werden (leer = aus), und Consumer-Gruppe
+	KafkaConsumeTopic  string `mapstructure:"kafka_consume_topic"`
+	KafkaConsumerGroup string `mapstructure:"kafka_consumer_group"`
+
+	// Port des gRPC-Endpunkts (leer = aus) und Obergrenze gleichzeitiger
+	// Streams je Verbindung (0 = Default von gRPC)
+	GRPCPort                 string `mapstructure:"grpc_port"`
+	GRPCMaxConcurrentStreams int
+
+	// Obergrenzen für die Query-Parameter count und size bei Dummy-Daten
+	MaxRequestEntityCount int
+	MaxRequestPayloadSize int
+
+	// Anteil der Entitäten eines Bulk-Requests, deren Speicherung simuliert fehlschlägt
+	BulkFailureRate float64
+
+	// Secret für HS256-Bearer-Tokens; gesetzt verlangen alle /api/base-Routen ein Token
+	JWTSecret string `mapstructure:"jwt_secret"`
+
+	// Erlaubte API-Keys; gesetzt verlangen alle /api/base-Routen einen davon in X-API-Key
+	APIKeys []string `mapstructure:"api_keys"`
+
+	// Origins, denen Browser-Zugriffe auf /api/base erlaubt sind ("*" für alle, leer = kein CORS)
+	CORSAllowedOrigins []string `mapstructure:"-"`
+
+	// Komprimiert Antworten ab GzipMinSize Bytes, wenn der Client gzip akzeptiert
+	Gzip        bool
+	GzipMinSize int
+
+	// Maximale Länge der Payload einer anzulegenden Entität
+	MaxPayloadLength int
+
+	// Vergabe von IDs für Entitäten ohne ID: uuid oder sequential (go-<n>)
+	IDStrategy string
+
+	// Zufällige alphanumerische statt aus 'x' bestehender Dummy-Payloads,
+	// reproduzierbar über PayloadSeed
+	RandomPayload bool
+	PayloadSeed   int64
+
+	// Gewichtungen der UpstreamServices und ob alle abgefragt werden (fanout)
+	// oder je Request einer per gewichtetem Round-Robin (balance)
+	UpstreamWeights map[string]int `mapstructure:"-"`
+	UpstreamMode    string
+
+	// Zusammenführung der Upstream-Ergebnisse: concat, first oder merge-dedup
+	AggregationStrategy string
+
+	// Erlaubt Fehlerinjektion per X-Inject-Delay und X-Inject-Status
+	FaultInjectionEnabled bool `mapstructure:"fault_injection_enabled"`
+
+	// Stellt die Profiling-Endpunkte unter /debug/pprof bereit
+	PprofEnabled bool `mapstructure:"pprof_enabled"`
+
+	// Timeouts des HTTP-Servers (0 = keine Begrenzung)
+	ServerReadTimeout  time.Duration
+	ServerWriteTimeout time.Duration
+	ServerIdleTimeout  time.Duration
+
+	// Index auf name und optional ein Textindex in MongoDB
+	MongoIndexes   bool
+	MongoTextIndex bool
+}
+
+// DatasourceConfig beschreibt die Verbindung zur SQL-Datenbank
+type DatasourceConfig struct {
+	Host     string
+	Port     string
+	DBName   string
+	Username string
+	Password string
+}
+
+// RedisConfig beschreibt die Verbindung zu Redis
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// MongoDBConfig entspricht MongoDbConfigProperties aus der Java-Anwendung
+type MongoDBConfig struct {
+	Host   string
+	Port   string
+	DBName string
 }
 
 // BaseDto entspricht der Datenstruktur aus der Java-Anwendung
 type BaseDto struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Payload string `json:"payload"`
+	ID        string     `json:"id" bson:"_id" xml:"id"`
+	Name      string     `json:"name" bson:"name" xml:"name"`
+	Payload   string     `json:"payload" bson:"payload" xml:"payload"`
+	Owner     string     `json:"owner,omitempty" bson:"owner,omitempty" xml:"owner,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty" xml:"updatedAt,omitempty"`
+
+	// Beim Lesen berechnete Felder, werden nicht gespeichert
+	PayloadLength *int   `json:"payloadLength,omitempty" bson:"-" xml:"payloadLength,omitempty"`
+	Checksum      string `json:"checksum,omitempty" bson:"-" xml:"checksum,omitempty"`
+
+	// Kodierung der Payload in der Antwort, leer bei unkomprimierter Payload
+	PayloadEncoding string `json:"payloadEncoding,omitempty" bson:"-" xml:"payloadEncoding,omitempty"`
+
+	// Dauer des Erzeugens bzw. Abrufens in Millisekunden
+	FetchDurationMs *float64 `json:"fetchDurationMs,omitempty" bson:"-" xml:"fetchDurationMs,omitempty"`
+
+	// IV der verschlüsselt gespeicherten Payload, wird nie ausgeliefert
+	PayloadIV string `json:"-" bson:"payloadIv,omitempty" xml:"-"`
 }
 
 var config MicrozooConfigProperties
 
-func loadConfig() {
-	viper.SetDefault("microzoo.requestDelay", "0ms")
-	viper.SetDefault("microzoo.responseDelay", "0ms")
-	viper.SetDefault("microzoo.entityCount", 1)
-	viper.SetDefault("microzoo.payloadSize", 100)
-	
+func loadConfig() error {
 	// Konfiguration aus Umgebungsvariablen laden
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("MICROZOO")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	// RequestDelay
-	reqDelayStr := viper.GetString("REQUESTDELAY")
-	if reqDelayStr == "" {
-		reqDelayStr = "0ms"
+	// Optionale Konfigurationsdatei (yaml|json) mit denselben Schlüsseln,
+	// Umgebungsvariablen haben weiterhin Vorrang
+	if configFile := viper.GetString("CONFIG_FILE"); configFile != "" {
+		viper.SetConfigFile(configFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("konnte Konfigurationsdatei %s nicht lesen: %w", configFile, err)
+		}
+		slog.Info("Read configuration file", "path", configFile)
 	}
-	reqDelay, err := time.ParseDuration(reqDelayStr)
+
+	for key, value := range configDefaults {
+		viper.SetDefault(key, value)
+	}
+
+	err := viper.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToListHook(),
+	)))
 	if err != nil {
-		log.Printf("WARN: Konnte RequestDelay nicht parsen: %v. Verwende 0ms.", err)
-		reqDelay = 0
+		return fmt.Errorf("konnte Konfiguration nicht lesen: %w", err)
 	}
-	config.RequestDelay = reqDelay
 
-	// ResponseDelay
-	respDelayStr := viper.GetString("RESPONSEDELAY")
-	if respDelayStr == "" {
-		respDelayStr = "0ms"
+	// UpstreamServices, optional mit Gewichtung "url=3"
+	config.UpstreamServices, config.UpstreamWeights = splitUpstreamWeights(config.UpstreamServices)
+
+	// EntityCount, optional als Bereich "min..max"
+	config.EntityCountMin, config.EntityCount = parseEntityCount(viper.GetString("ENTITYCOUNT"))
+	config.EntityCountMode = strings.ToLower(config.EntityCountMode)
+	if config.EntityCountMode != entityCountModeRequest {
+		config.EntityCountMode = entityCountModeRandom
 	}
-	respDelay, err := time.ParseDuration(respDelayStr)
-	if err != nil {
-		log.Printf("WARN: Konnte ResponseDelay nicht parsen: %v. Verwende 0ms.", err)
-		respDelay = 0
+
+	// AdmissionMode (fifo|fair)
+	config.AdmissionMode = strings.ToLower(config.AdmissionMode)
+	if config.AdmissionMode != admissionModeFair {
+		config.AdmissionMode = admissionModeFifo
 	}
-	config.ResponseDelay = respDelay
 
-	// UpstreamServices
-	upstreamStr := viper.GetString("UPSTREAMSERVICES")
-	if upstreamStr != "" {
-		config.UpstreamServices = strings.Split(upstreamStr, ",")
-	} else {
-		config.UpstreamServices = []string{}
+	// UpstreamRoutes
+	config.UpstreamRoutes = parseUpstreamRoutes(viper.GetString("UPSTREAMROUTES"))
+
+	// IdempotencyStore
+	config.IdempotencyStore = strings.ToLower(config.IdempotencyStore)
+	if config.IdempotencyStore != idempotencyStoreDB {
+		config.IdempotencyStore = idempotencyStoreMemory
 	}
 
-	// EntityCount
-	entityCountStr := viper.GetString("ENTITYCOUNT")
-	if entityCountStr != "" {
-		config.EntityCount, err = strconv.Atoi(entityCountStr)
-		if err != nil {
-			log.Printf("WARN: Konnte EntityCount nicht parsen: %v. Verwende 1.", err)
-			config.EntityCount = 1
-		}
-	} else {
-		config.EntityCount = 1
+	// ErrorFormat
+	config.ErrorFormat = strings.ToLower(config.ErrorFormat)
+	if config.ErrorFormat != errorFormatProblem {
+		config.ErrorFormat = errorFormatEnvelope
 	}
 
-	// PayloadSize
-	payloadSizeStr := viper.GetString("PAYLOADSIZE")
-	if payloadSizeStr != "" {
-		config.PayloadSize, err = strconv.Atoi(payloadSizeStr)
-		if err != nil {
-			log.Printf("WARN: Konnte PayloadSize nicht parsen: %v. Verwende 100.", err)
-			config.PayloadSize = 100
+	// DBDriver bestimmt auch den Default-Port der Datasource
+	config.DBDriver = strings.ToLower(config.DBDriver)
+	switch config.DBDriver {
+	case dbDriverPostgres, dbDriverMySQL:
+	case "":
+		config.DBDriver = dbDriverPostgres
+	default:
+		slog.Warn("Unbekannter DBDriver, verwende Default", "dbDriver", config.DBDriver, "default", dbDriverPostgres)
+		config.DBDriver = dbDriverPostgres
+	}
+	if config.Datasource.Port == "" {
+		config.Datasource.Port = "5432"
+		if config.DBDriver == dbDriverMySQL {
+			config.Datasource.Port = "3306"
 		}
-	} else {
-		config.PayloadSize = 100
 	}
+	if config.DBReconnectMaxBackoff < time.Second {
+		config.DBReconnectMaxBackoff = time.Second
+	}
+
+	// ErrorProfiles
+	config.ErrorProfiles = parseErrorProfiles(viper.GetString("ERRORPROFILES"))
+
+	// UpstreamFieldMappings
+	config.UpstreamFieldMappings = parseFieldMappings(viper.GetString("UPSTREAMFIELDMAPPINGS"))
+
+	// UpstreamBackups
+	config.UpstreamBackups = parseUpstreamBackups(viper.GetString("UPSTREAM_BACKUPS"))
 
-	log.Printf("Konfiguration geladen: %+v", config)
+	// ComputedFields
+	config.ComputedFields = parseComputedFields(viper.GetString("COMPUTEDFIELDS"))
+
+	// StatusSequences
+	config.StatusSequences = parseStatusSequences(viper.GetString("STATUSSEQUENCES"))
+
+	// RequestTransform
+	config.RequestTransform = parseRequestTransform(viper.GetString("REQUESTTRANSFORM"))
+
+	// ShutdownMode
+	config.ShutdownMode = strings.ToLower(config.ShutdownMode)
+	if config.ShutdownMode != shutdownModeDrainRejecting {
+		config.ShutdownMode = shutdownModeDrainServing
+	}
+
+	// DowntimeWindows
+	config.DowntimeWindows = parseDowntimeWindows(viper.GetString("DOWNTIMEWINDOWS"))
+
+	// FieldOrder
+	config.FieldOrder = parseFieldOrder(viper.GetString("FIELDORDER"))
+
+	// LogLevel
+	config.LogLevel = parseLogLevel(viper.GetString("LOGLEVEL"))
+
+	// Ohne Angabe reicht der Burst für eine Sekunde
+	if config.RateLimitBurst == 0 {
+		config.RateLimitBurst = max(1, int(math.Ceil(config.RateLimitRPS)))
+	}
+
+	// DelayDistribution
+	config.DelayDistribution = parseDelayDistribution(config.DelayDistribution)
+
+	// KafkaFailMode
+	config.KafkaFailMode = strings.ToLower(config.KafkaFailMode)
+	if config.KafkaFailMode != kafkaFailModeFatal {
+		config.KafkaFailMode = kafkaFailModeBestEffort
+	}
+	if config.KafkaConsumerGroup == "" {
+		config.KafkaConsumerGroup = "microzoo"
+	}
+
+	// CORSAllowedOrigins
+	config.CORSAllowedOrigins = parseCORSOrigins(viper.GetString("CORS_ALLOWED_ORIGINS"))
+
+	// IDStrategy
+	config.IDStrategy = parseIDStrategy(config.IDStrategy)
+
+	// UpstreamMode
+	config.UpstreamMode = parseUpstreamMode(config.UpstreamMode)
+
+	// AggregationStrategy
+	config.AggregationStrategy = parseAggregationStrategy(config.AggregationStrategy)
+
+	if err := validateConfig(); err != nil {
+		return err
+	}
+	if config.ServerWriteTimeout > 0 && config.ServerWriteTimeout <= config.RequestDelay+config.ResponseDelay {
+		slog.Warn("ServerWriteTimeout ist nicht größer als RequestDelay und ResponseDelay zusammen, Antworten werden abgebrochen",
+			"serverWriteTimeout", config.ServerWriteTimeout.String())
+	}
+
+	slog.Info("Loaded configuration", "config", fmt.Sprintf("%+v", redactedConfig()))
+	return nil
+}
+
+// redactedConfig liefert eine Kopie der Konfiguration ohne Geheimnisse für das Logging
+func redactedConfig() MicrozooConfigProperties {
+	redacted := config
+	if redacted.HmacSecret != "" {
+		redacted.HmacSecret = "***"
+	}
+	if redacted.JWTSecret != "" {
+		redacted.JWTSecret = "***"
+	}
+	if len(redacted.APIKeys) > 0 {
+		redacted.APIKeys = []string{"***"}
+	}
+	if redacted.Datasource.Password != "" {
+		redacted.Datasource.Password = "***"
+	}
+	if redacted.Redis.Password != "" {
+		redacted.Redis.Password = "***"
+	}
+	if redacted.EncryptionKey != "" {
+		redacted.EncryptionKey = "***"
+	}
+	return redacted
+}
+
+// generateBaseDtoForID erzeugt eine Dummy-Entität zu einer beliebigen ID. IDs
+// der Form "go-<n>" ergeben dieselbe Entität wie in getAll.
+func generateBaseDtoForID(id string) BaseDto {
+	if numStr, ok := strings.CutPrefix(id, "go-"); ok {
+		if num, err := strconv.Atoi(numStr); err == nil {
+			return generateBaseDto(num, config.PayloadSize)
+		}
+	}
+	dto := BaseDto{
+		ID:      id,
+		Name:    "Go Entity " + id,
+		Payload: generatePayload(id, config.PayloadSize),
+	}
+	observePayloadSize("generated", dto)
+	return dto
 }
 
-func generateBaseDto(id int) BaseDto {
-	payload := strings.Repeat("x", config.PayloadSize)
-	return BaseDto{
-		ID:      fmt.Sprintf("go-%d", id),
+func generateBaseDto(id, payloadSize int) BaseDto {
+	dtoID := fmt.Sprintf("go-%d", id)
+	dto := BaseDto{
+		ID:      dtoID,
 		Name:    fmt.Sprintf("Go Entity %d", id),
-		Payload: payload,
+		Payload: generatePayload(dtoID, payloadSize),
 	}
+	observePayloadSize("generated", dto)
+	return dto
 }
 
 func getAll(c *gin.Context) {
-	log.Println("Entered GET /api/base")
-	time.Sleep(config.RequestDelay)
-
-	// Simuliere die Logik aus BaseService.java
-	// Da wir keine Datenbank haben, simulieren wir nur die "No-Database"-Logik und Upstream-Aufrufe
-
-	// 1. Fall: Upstream-Services sind konfiguriert
-	if len(config.UpstreamServices) > 0 {
-		log.Println("Fetching entities from upstream services")
-		var dtos []BaseDto
-		
-		// Hier müsste die Logik für FeignClients/HTTP-Aufrufe zu Upstream-Services implementiert werden.
-		// Für diese Demonstration wird dies vereinfacht und nur die Struktur gezeigt.
-		// In einer vollständigen Implementierung würde man hier HTTP-Clients verwenden.
-		
-		// Simuliere den Aufruf und die Aggregation
-		for _, serviceURL := range config.UpstreamServices {
-			log.Printf("Delegating call to %s/api/base", serviceURL)
-			// Echter HTTP-Aufruf würde hier erfolgen
-			// Für die Demo geben wir einfach ein Dummy-Ergebnis zurück
-			dtos = append(dtos, BaseDto{
-				ID: fmt.Sprintf("upstream-%s-1", serviceURL),
-				Name: fmt.Sprintf("Upstream Entity from %s", serviceURL),
-				Payload: strings.Repeat("y", config.PayloadSize),
-			})
+	slog.DebugContext(c.Request.Context(), "Entered GET /api/base")
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+	if !acceptsOfferedFormat(c) {
+		return
+	}
+
+	burnCPU(c.Request.Context())
+	defer runtime.KeepAlive(allocateChurn())
+
+	var query listQuery
+	var err error
+	query.lastID, query.paged, err = requestedCursor(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.since, query.incremental, err = requestedUpdatedSince(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.paged && query.incremental {
+		respondError(c, http.StatusBadRequest, "cursor and updatedSince cannot be combined")
+		return
+	}
+	query.text = c.Query("q")
+	if query.paged && query.text != "" {
+		respondError(c, http.StatusBadRequest, "cursor and q cannot be combined")
+		return
+	}
+	query.limit, query.offset, err = requestedLimitOffset(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.dummyShape = func() (int, int, error) {
+		count, size, err := requestedDummyShape(c)
+		// Bei Cursor-Paginierung gilt ohne count immer die Obergrenze, damit die Seiten zueinander passen
+		if _, present := c.GetQuery("count"); query.paged && !present {
+			count = config.EntityCount
 		}
-		
-		time.Sleep(config.ResponseDelay)
-		log.Println("Exiting GET /api/base (Upstream)")
-		c.JSON(http.StatusOK, dtos)
+		return count, size, err
+	}
+
+	dtos, visible, source, err := listEntities(httpScope(c), query)
+	if err != nil {
+		respondError(c, errorStatus(err), err.Error())
 		return
 	}
 
-	// 2. Fall: Keine Datenbank, keine Upstream-Services (Generierung von Dummy-Daten)
-	log.Println("Generating dummy entities")
-	var dtos []BaseDto
-	for i := 1; i <= config.EntityCount; i++ {
-		dtos = append(dtos, generateBaseDto(i))
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(c.Request.Context(), "Exiting GET /api/base", "source", source, "count", len(dtos))
+	switch {
+	case source == "upstream":
+		writeAggregatedResponse(c, visible)
+	case query.paged:
+		respondNegotiated(c, http.StatusOK, newCursorPage(dtos, visible))
+	default:
+		respondNegotiated(c, http.StatusOK, visible)
 	}
+}
 
-	time.Sleep(config.ResponseDelay)
-	log.Println("Exiting GET /api/base (Dummy)")
-	c.JSON(http.StatusOK, dtos)
+func getOne(c *gin.Context) {
+	id := c.Param("id")
+	slog.DebugContext(c.Request.Context(), "Entered GET /api/base/:id", "id", id)
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+	if !acceptsOfferedFormat(c) {
+		return
+	}
+
+	// Ohne Datenbank wird die Entität aus der ID generiert
+	if !isDBActive() {
+		dto := generateBaseDtoForID(id)
+		time.Sleep(currentResponseDelay())
+		slog.DebugContext(c.Request.Context(), "Exiting GET /api/base/:id", "source", "dummy", "id", id)
+		respondNegotiated(c, http.StatusOK, dto)
+		return
+	}
+
+	dto, found, err := getOneFromDB(c.Request.Context(), id)
+	if err != nil {
+		entry, ok := staleEntities.get(id)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht aus der Datenbank lesen", "id", id, "error", err)
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		slog.WarnContext(c.Request.Context(), "Datenbankfehler beim Lesen, liefere gecachte Fassung", "id", id, "error", err)
+		markStale(c, entry)
+		dto, found = entry.dto, true
+	} else if found {
+		staleEntities.put(dto)
+	}
+
+	time.Sleep(currentResponseDelay())
+	if !found {
+		respondError(c, http.StatusNotFound, "entity "+id+" not found")
+		return
+	}
+	if !canAccess(callerIdentity(c), dto) {
+		respondError(c, http.StatusForbidden, "access to entity "+id+" denied")
+		return
+	}
+	slog.DebugContext(c.Request.Context(), "Exiting GET /api/base/:id", "source", "repository", "id", id)
+	respondNegotiated(c, http.StatusOK, dto)
 }
 
 func create(c *gin.Context) {
-	log.Println("Entered POST /api/base")
-	time.Sleep(config.RequestDelay)
+	slog.DebugContext(c.Request.Context(), "Entered POST /api/base")
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+	if !acceptsOfferedFormat(c) {
+		return
+	}
+
+	burnCPU(c.Request.Context())
+	defer runtime.KeepAlive(allocateChurn())
+
+	if reserveIdempotencyKey(c) {
+		return
+	}
+	defer releaseIdempotencyKey(c)
+
+	var baseDto BaseDto
+	if err := c.ShouldBindJSON(&baseDto); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, source, err := createEntity(httpScope(c), baseDto)
+	if err != nil {
+		respondError(c, errorStatus(err), err.Error())
+		return
+	}
+
+	storeIdempotentResult(c, result)
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(c.Request.Context(), "Exiting POST /api/base", "source", source, "id", result.ID)
+	respondNegotiated(c, http.StatusCreated, result)
+}
+
+func updateOne(c *gin.Context) {
+	id := c.Param("id")
+	slog.DebugContext(c.Request.Context(), "Entered PUT /api/base/:id", "id", id)
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
 
 	var baseDto BaseDto
 	if err := c.ShouldBindJSON(&baseDto); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	baseDto.ID = id
+	assignOwner(callerIdentity(c), &baseDto)
+
+	// 1. Fall: Datenbank ist konfiguriert
+	if isDBActive() {
+		allowed, err := canOverwrite(c.Request.Context(), callerIdentity(c), id)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht aus der Datenbank lesen", "id", id, "error", err)
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !allowed {
+			respondError(c, http.StatusForbidden, "access to entity "+id+" denied")
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Replacing entity in repository", "id", id)
+		result, found, err := updateInDB(c.Request.Context(), baseDto)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht ersetzen", "id", id, "error", err)
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if found {
+			listCache.invalidate()
+			staleEntities.put(result)
+		}
+
+		time.Sleep(currentResponseDelay())
+		if !found {
+			respondError(c, http.StatusNotFound, "entity "+id+" not found")
+			return
+		}
+		recordHistory(c.Request.Context(), result)
+		slog.DebugContext(c.Request.Context(), "Exiting PUT /api/base/:id", "source", "repository", "id", id)
+		c.JSON(http.StatusOK, result)
 		return
 	}
 
-	// Simuliere die Logik aus BaseService.java
-	// 1. Fall: Upstream-Services sind konfiguriert
-	if len(config.UpstreamServices) > 0 {
-		log.Printf("Posting dto with id %s to upstream services", baseDto.ID)
-		
-		// Hier müsste die Logik für FeignClients/HTTP-Aufrufe zu Upstream-Services implementiert werden.
-		// Für diese Demonstration wird dies vereinfacht.
-		
-		// Simuliere den Aufruf und die Rückgabe
-		for _, serviceURL := range config.UpstreamServices {
-			log.Printf("Posting dto with id %s to service %s", baseDto.ID, serviceURL)
-			// Echter HTTP-Aufruf würde hier erfolgen
+	// 2. Fall: Upstream-Services sind konfiguriert
+	upstreams := selectUpstreams(c)
+	if len(upstreams) > 0 {
+		slog.InfoContext(c.Request.Context(), "Putting entity to upstream services", "id", id, "upstreams", upstreams)
+
+		// Ergebnis ist wie bei create die Antwort des letzten erfolgreichen Upstreams
+		var result BaseDto
+		for _, serviceURL := range upstreams {
+			echoed, err := withFailover(c.Request.Context(), serviceURL, func(serviceURL string) (BaseDto, error) {
+				return putToUpstream(c.Request.Context(), serviceURL, baseDto)
+			})
+			var statusErr *upstreamStatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+				respondError(c, http.StatusNotFound, "entity "+id+" not found in "+serviceURL)
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht übergeben", "id", id, "upstream", serviceURL, "error", err)
+				respondError(c, upstreamFailureStatus(err), err.Error())
+				return
+			}
+			result = echoed
 		}
-		
-		time.Sleep(config.ResponseDelay)
-		log.Println("Exiting POST /api/base (Upstream)")
-		c.JSON(http.StatusCreated, baseDto)
+
+		recordHistory(c.Request.Context(), result)
+		time.Sleep(currentResponseDelay())
+		slog.DebugContext(c.Request.Context(), "Exiting PUT /api/base/:id", "source", "upstream", "id", id)
+		c.JSON(http.StatusOK, result)
 		return
 	}
 
-	// 2. Fall: Keine Datenbank, keine Upstream-Services (einfache Rückgabe)
-	time.Sleep(config.ResponseDelay)
-	log.Println("Exiting POST /api/base (No-DB)")
-	c.JSON(http.StatusCreated, baseDto)
+	// 3. Fall: Keine Datenbank, keine Upstream-Services (einfache Rückgabe)
+	recordHistory(c.Request.Context(), baseDto)
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(c.Request.Context(), "Exiting PUT /api/base/:id", "source", "none", "id", id)
+	c.JSON(http.StatusOK, baseDto)
+}
+
+func deleteOne(c *gin.Context) {
+	id := c.Param("id")
+	slog.DebugContext(c.Request.Context(), "Entered DELETE /api/base/:id", "id", id)
+	time.Sleep(currentRequestDelay())
+
+	if injectEndpointError(c) {
+		return
+	}
+
+	// 1. Fall: Datenbank ist konfiguriert
+	if isDBActive() {
+		if config.AccessControl {
+			dto, found, err := getOneFromDB(c.Request.Context(), id)
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht aus der Datenbank lesen", "id", id, "error", err)
+				respondError(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if found && !canAccess(callerIdentity(c), dto) {
+				respondError(c, http.StatusForbidden, "access to entity "+id+" denied")
+				return
+			}
+		}
+
+		slog.InfoContext(c.Request.Context(), "Deleting entity in repository", "id", id)
+		found, err := deleteFromDB(c.Request.Context(), id)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht löschen", "id", id, "error", err)
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		staleEntities.remove(id)
+		listCache.invalidate()
+
+		time.Sleep(currentResponseDelay())
+		if !found {
+			respondError(c, http.StatusNotFound, "entity "+id+" not found")
+			return
+		}
+		slog.DebugContext(c.Request.Context(), "Exiting DELETE /api/base/:id", "source", "repository", "id", id)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// 2. Fall: Upstream-Services sind konfiguriert
+	upstreams := selectUpstreams(c)
+	if len(upstreams) > 0 {
+		slog.InfoContext(c.Request.Context(), "Deleting entity in upstream services", "id", id, "upstreams", upstreams)
+		deleted := false
+		for _, serviceURL := range upstreams {
+			found, err := withFailover(c.Request.Context(), serviceURL, func(serviceURL string) (bool, error) {
+				return deleteFromUpstream(c.Request.Context(), serviceURL, id)
+			})
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Konnte Entität nicht löschen", "id", id, "upstream", serviceURL, "error", err)
+				respondError(c, upstreamFailureStatus(err), err.Error())
+				return
+			}
+			deleted = deleted || found
+		}
+
+		time.Sleep(currentResponseDelay())
+		if !deleted {
+			respondError(c, http.StatusNotFound, "entity "+id+" not found")
+			return
+		}
+		slog.DebugContext(c.Request.Context(), "Exiting DELETE /api/base/:id", "source", "upstream", "id", id)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// 3. Fall: Keine Datenbank, keine Upstream-Services (nichts zu löschen)
+	time.Sleep(currentResponseDelay())
+	slog.DebugContext(c.Request.Context(), "Exiting DELETE /api/base/:id", "source", "none", "id", id)
+	c.Status(http.StatusNoContent)
 }
 
 func main() {
-	loadConfig()
+	if err := loadConfig(); err != nil {
+		slog.Error("Ungültige Konfiguration", "error", err)
+		os.Exit(1)
+	}
+	initLogger()
+	allocateBallast()
+	initEncryption()
+	upstreamClient = newUpstreamClient()
+
+	if config.DelayFile != "" {
+		watchDelayFile(config.DelayFile)
+	}
+	initDB()
+	initIdempotencyStore()
+	initKafkaProducer()
+	startKafkaConsumer()
 
 	// Gin im Release-Modus für weniger Log-Ausgabe
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(gin.Recovery())
+	router.Use(requestIDMiddleware())
+	router.Use(requestLogMiddleware())
+	router.Use(requestMetricsMiddleware())
+	if len(config.CORSAllowedOrigins) > 0 {
+		router.Use(corsMiddleware())
+	}
+	if config.TracingEndpoint != "" {
+		initOtelTracing()
+		router.Use(otelTracingMiddleware())
+	}
+	if config.OtelEndpoint != "" {
+		initOtelMetrics()
+		router.Use(otelMetricsMiddleware())
+	}
+	if config.TraceBufferSize > 0 {
+		router.Use(traceMiddleware())
+	}
+	if config.AccessLogFile != "" {
+		router.Use(accessLogMiddleware())
+	}
+	if config.GlobalBandwidthBytesPerSec > 0 {
+		router.Use(bandwidthMiddleware())
+	}
+	// Muss vor allen Middlewares registriert werden, die den Body puffern
+	if config.HTTP10 {
+		router.Use(http10Middleware())
+	}
+	if config.Version != "" {
+		registerVersion()
+		router.Use(versionMiddleware())
+	}
+	// Vor der Signatur registriert, damit über den unkomprimierten Body signiert wird
+	if config.Gzip {
+		router.Use(gzipMiddleware())
+	}
+	if isSigningEnabled() {
+		router.Use(signatureMiddleware())
+	}
 
-	// Health Check Endpunkt
-	router.GET("/actuator/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "UP"})
-	})
+	// Health Check Endpunkte
+	router.GET("/actuator/health", liveness)
+	router.GET("/actuator/health/liveness", liveness)
+	router.GET("/actuator/health/readiness", readiness)
+
+	// Profiling, nur wenn ausdrücklich aktiviert
+	if config.PprofEnabled {
+		registerPprof(router)
+	}
+
+	// Zustände der Circuit Breaker pro Upstream
+	router.GET("/actuator/breakers", getBreakers)
+
+	// Zusammenfassung aller Abhängigkeiten für Dashboards
+	router.GET("/actuator/health/summary", healthSummary)
+
+	// Synthetische Schreib-/Lese-Transaktion gegen das Backend
+	router.GET("/actuator/selftest", selftest)
+
+	// Prometheus Metriken
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	if config.TraceBufferSize > 0 {
+		router.GET("/actuator/traces", getTraces)
+	}
 
 	// REST Endpunkte
+	limits := newAPILimits()
 	api := router.Group("/api/base")
+	if config.JWTSecret != "" {
+		api.Use(jwtMiddleware())
+	}
+	if len(config.APIKeys) > 0 {
+		api.Use(apiKeyMiddleware())
+	}
+	if len(config.DowntimeWindows) > 0 {
+		api.Use(downtimeMiddleware())
+	}
+	if config.RateLimitRPS > 0 {
+		api.Use(rateLimitMiddleware(limits.rateLimiter))
+	}
+	if config.DegradeConcurrencyThreshold > 0 {
+		api.Use(inFlightMiddleware())
+	}
+	if config.MaxConcurrentRequests > 0 {
+		api.Use(admissionMiddleware(limits.scheduler))
+	}
+	if config.MaxConcurrentReads > 0 || config.MaxConcurrentWrites > 0 {
+		api.Use(methodLimitMiddleware(limits.methods))
+	}
+	if config.FaultInjectionEnabled {
+		api.Use(faultInjectionMiddleware())
+	}
 	{
 		api.GET("/", getAll)
+		api.GET("/digest", getDigest)
+		api.GET("/count", getCount)
+		api.GET("/:id", getOne)
 		api.POST("/", create)
+		api.POST("/bulk", createBulk)
+		api.PUT("/:id", updateOne)
+		api.DELETE("/:id", deleteOne)
+		if config.KeepHistory {
+			api.GET("/:id/history", getHistory)
+		}
+	}
+	if config.CustomNotFound {
+		router.NoRoute(notFoundHandler(router))
 	}
 
 	port := os.Getenv("PORT")
@@ -217,8 +1015,16 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Go Service gestartet auf Port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Konnte Server nicht starten: %v", err)
-	}
+	startWarmup(router)
+
+	startGRPCServer(limits)
+
+	slog.Info("Go service started", "port", port)
+	runServer(&http.Server{
+		Addr:         ":" + port,
+		Handler:      router,
+		ReadTimeout:  config.ServerReadTimeout,
+		WriteTimeout: config.ServerWriteTimeout,
+		IdleTimeout:  config.ServerIdleTimeout,
+	})
 }
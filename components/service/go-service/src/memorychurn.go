@@ -0,0 +1,20 @@
+package main
+
+// Abstand, in dem die Bytes beschrieben werden; eine Seite pro Schreibzugriff
+// genügt, damit der Speicher tatsächlich belegt wird
+const churnPageSize = 4096
+
+// allocateChurn belegt pro Request MemoryChurnBytes und beschreibt jede Seite,
+// sodass die Allokation weder wegoptimiert wird noch unberührt bleibt. Der
+// Aufrufer hält das Ergebnis bis zum Ende des Handlers (runtime.KeepAlive),
+// danach kann der GC es freigeben.
+func allocateChurn() []byte {
+	if config.MemoryChurnBytes <= 0 {
+		return nil
+	}
+	churn := make([]byte, config.MemoryChurnBytes)
+	for i := 0; i < len(churn); i += churnPageSize {
+		churn[i] = byte(i)
+	}
+	return churn
+}
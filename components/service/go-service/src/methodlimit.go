@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// methodLimiter begrenzt lesende (GET, HEAD) und schreibende Requests mit
+// getrennten Semaphoren. Ein Limit von 0 bedeutet unbegrenzt.
+type methodLimiter struct {
+	reads  chan struct{}
+	writes chan struct{}
+}
+
+func newMethodLimiter() *methodLimiter {
+	limiter := &methodLimiter{}
+	if config.MaxConcurrentReads > 0 {
+		limiter.reads = make(chan struct{}, config.MaxConcurrentReads)
+	}
+	if config.MaxConcurrentWrites > 0 {
+		limiter.writes = make(chan struct{}, config.MaxConcurrentWrites)
+	}
+	return limiter
+}
+
+// tryAcquire belegt sofort einen Slot der passenden Art und liefert die
+// Funktion zur Freigabe. Ist das Limit erreicht, wird nicht gewartet.
+func (l *methodLimiter) tryAcquire(write bool) (release func(), kind string, ok bool) {
+	semaphore, kind := l.reads, "read"
+	if write {
+		semaphore, kind = l.writes, "write"
+	}
+	if semaphore == nil {
+		return func() {}, kind, true
+	}
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, kind, true
+	default:
+		return nil, kind, false
+	}
+}
+
+// limit liefert das Limit der Art
+func (l *methodLimiter) limit(write bool) int {
+	if write {
+		return cap(l.writes)
+	}
+	return cap(l.reads)
+}
+
+// methodLimitMiddleware weist Requests sofort mit 503 ab, statt zu warten,
+// wenn das Limit ihrer Art erreicht ist
+func methodLimitMiddleware(limiter *methodLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+		release, kind, ok := limiter.tryAcquire(write)
+		if !ok {
+			slog.InfoContext(c.Request.Context(), "Rejecting request, concurrency limit reached", "method", c.Request.Method, "path", c.Request.URL.Path, "kind", kind, "limit", limiter.limit(write))
+			abortWithError(c, http.StatusServiceUnavailable, kind+" concurrency limit reached")
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
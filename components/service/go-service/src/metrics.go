@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var payloadSizeBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "microzoo_payload_size_bytes",
+	Help:    "Größe der Payloads generierter (generated), gespeicherter (stored) und ausgelieferter (returned) Entitäten",
+	Buckets: prometheus.ExponentialBuckets(16, 2, 16),
+}, []string{"source"})
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "microzoo_http_requests_total",
+		Help: "Anzahl der bearbeiteten HTTP-Requests",
+	}, []string{"method", "path", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "microzoo_http_request_duration_seconds",
+		Help:    "Bearbeitungsdauer der HTTP-Requests",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+	upstreamCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "microzoo_upstream_calls_total",
+		Help: "Anzahl der Aufrufe an Upstream-Services",
+	}, []string{"upstream", "method", "outcome"})
+	dbOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "microzoo_db_operations_total",
+		Help: "Anzahl der Datenbankoperationen",
+	}, []string{"backend", "operation", "outcome"})
+)
+
+func observePayloadSize(source string, dto BaseDto) {
+	payloadSizeBytes.WithLabelValues(source).Observe(float64(len(dto.Payload)))
+}
+
+func observePayloadSizes(source string, dtos []BaseDto) {
+	observer := payloadSizeBytes.WithLabelValues(source)
+	for _, dto := range dtos {
+		observer.Observe(float64(len(dto.Payload)))
+	}
+}
+
+// requestMetricsMiddleware erfasst Anzahl und Dauer aller Requests. Als Pfad
+// wird die Route verwendet, damit IDs die Anzahl der Zeitreihen nicht aufblähen.
+func requestMetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		labels := prometheus.Labels{
+			"method": c.Request.Method,
+			"path":   path,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+		httpRequestsTotal.With(labels).Inc()
+		httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+func metricsOutcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+func observeUpstreamCall(serviceURL, method string, err error) {
+	upstreamCallsTotal.WithLabelValues(serviceURL, method, metricsOutcome(err)).Inc()
+}
+
+func observeDBOperation(operation string, err error) {
+	dbOperationsTotal.WithLabelValues(dbBackendName(), operation, metricsOutcome(err)).Inc()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// offeredFormats sind die Antwortformate von getAll, getOne und create. JSON
+// steht vorn, damit */* und ein fehlender Accept-Header JSON liefern.
+var offeredFormats = []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2}
+
+// xmlEntity ist das Wurzelelement einer einzelnen Entität in XML
+type xmlEntity struct {
+	XMLName xml.Name `xml:"entity"`
+	BaseDto
+}
+
+// xmlEntityList ist das Wurzelelement einer Liste von Entitäten in XML
+type xmlEntityList struct {
+	XMLName  xml.Name  `xml:"entities"`
+	Entities []BaseDto `xml:"entity"`
+}
+
+// acceptsOfferedFormat beantwortet den Request mit 406, wenn der Client per
+// Accept ausschließlich Formate verlangt, die nicht angeboten werden. Ohne
+// Accept-Header wird immer JSON geliefert.
+func acceptsOfferedFormat(c *gin.Context) bool {
+	if c.GetHeader("Accept") == "" || c.NegotiateFormat(offeredFormats...) != "" {
+		return true
+	}
+	respondError(c, http.StatusNotAcceptable, "supported response formats: "+binding.MIMEJSON+", "+binding.MIMEXML)
+	return false
+}
+
+// wantsXML liefert true, wenn der Client XML gegenüber JSON bevorzugt
+func wantsXML(c *gin.Context) bool {
+	if c.GetHeader("Accept") == "" {
+		return false
+	}
+	format := c.NegotiateFormat(offeredFormats...)
+	return format == binding.MIMEXML || format == binding.MIMEXML2
+}
+
+// respondNegotiated schreibt body als XML, wenn der Client es verlangt, sonst
+// als JSON
+func respondNegotiated(c *gin.Context, status int, body any) {
+	simulateSerialization(entityCount(body))
+	if !wantsXML(c) {
+		c.JSON(status, body)
+		return
+	}
+	switch value := body.(type) {
+	case BaseDto:
+		c.XML(status, xmlEntity{BaseDto: value})
+	case []BaseDto:
+		c.XML(status, xmlEntityList{Entities: value})
+	default:
+		c.XML(status, body)
+	}
+}
+
+// simulateSerialization wartet SerializationDelayPerEntity je Entität der
+// Antwort, sodass die Serialisierungskosten proportional zur Anzahl der
+// Entitäten steigen. Interne Serialisierungen (Cache, Kafka, Upstreams) sind
+// davon nicht betroffen.
+func simulateSerialization(entities int) {
+	if config.SerializationDelayPerEntity > 0 && entities > 0 {
+		time.Sleep(time.Duration(entities) * config.SerializationDelayPerEntity)
+	}
+}
+
+// entityCount liefert die Anzahl der Entitäten in einer Antwort
+func entityCount(body any) int {
+	switch value := body.(type) {
+	case BaseDto:
+		return 1
+	case []BaseDto:
+		return len(value)
+	case CursorPage:
+		return len(value.Items)
+	}
+	return 0
+}
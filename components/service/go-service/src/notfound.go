@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notFoundProblem erweitert ProblemDetails um die Felder der eigenen 404-Antwort
+type notFoundProblem struct {
+	ProblemDetails
+	RequestID string   `json:"requestId,omitempty"`
+	Routes    []string `json:"routes,omitempty"`
+}
+
+// notFoundHandler beantwortet unbekannte Routen mit einem eigenen 404 im
+// konfigurierten Fehlerformat. Mit NotFoundListRoutes enthält die Antwort
+// zusätzlich alle registrierten Routen.
+func notFoundHandler(router *gin.Engine) gin.HandlerFunc {
+	var routes []string
+	if config.NotFoundListRoutes {
+		for _, route := range router.Routes() {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+	}
+
+	return func(c *gin.Context) {
+		slog.InfoContext(c.Request.Context(), "No route", "method", c.Request.Method, "path", c.Request.URL.Path)
+		detail := "no route for " + c.Request.Method + " " + c.Request.URL.Path
+		requestID := c.GetHeader("X-Request-Id")
+
+		if config.ErrorFormat != errorFormatProblem {
+			body := gin.H{"error": detail, "service": "microzoo go-service"}
+			if requestID != "" {
+				body["requestId"] = requestID
+			}
+			if routes != nil {
+				body["routes"] = routes
+			}
+			c.JSON(http.StatusNotFound, body)
+			return
+		}
+
+		c.Render(http.StatusNotFound, problemRender{notFoundProblem{
+			ProblemDetails: ProblemDetails{
+				Type:     "about:blank",
+				Title:    http.StatusText(http.StatusNotFound),
+				Status:   http.StatusNotFound,
+				Detail:   detail,
+				Instance: c.Request.URL.RequestURI(),
+			},
+			RequestID: requestID,
+			Routes:    routes,
+		}})
+	}
+}
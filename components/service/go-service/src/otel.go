@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// otelMetrics bündelt die OpenTelemetry-Instrumente. Ist kein Endpunkt
+// konfiguriert, bleibt otelInstruments nil und es wird nichts erfasst.
+type otelMetrics struct {
+	requests        metric.Int64Counter
+	requestDuration metric.Float64Histogram
+	upstreamCalls   metric.Int64Counter
+}
+
+var (
+	meterProvider   *sdkmetric.MeterProvider
+	otelInstruments *otelMetrics
+)
+
+// initOtelMetrics richtet den OTLP-Export der Metriken an den Collector unter
+// OtelEndpoint ein
+func initOtelMetrics() {
+	exporter, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpoint(config.OtelEndpoint),
+		otlpmetrichttp.WithInsecure())
+	if err != nil {
+		slog.Warn("Konnte OTLP-Metrik-Exporter nicht erstellen, Metriken werden nicht exportiert", "error", err)
+		return
+	}
+	meterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+	otel.SetMeterProvider(meterProvider)
+
+	meter := meterProvider.Meter("microzoo/go-service")
+	instruments := &otelMetrics{}
+	if instruments.requests, err = meter.Int64Counter("microzoo.requests",
+		metric.WithDescription("Anzahl der bearbeiteten Requests")); err != nil {
+		slog.Warn("Konnte OTel-Instrument nicht erstellen", "error", err)
+		return
+	}
+	if instruments.requestDuration, err = meter.Float64Histogram("microzoo.request.duration",
+		metric.WithDescription("Bearbeitungsdauer der Requests"), metric.WithUnit("s")); err != nil {
+		slog.Warn("Konnte OTel-Instrument nicht erstellen", "error", err)
+		return
+	}
+	if instruments.upstreamCalls, err = meter.Int64Counter("microzoo.upstream.calls",
+		metric.WithDescription("Anzahl der Aufrufe an Upstream-Services")); err != nil {
+		slog.Warn("Konnte OTel-Instrument nicht erstellen", "error", err)
+		return
+	}
+	otelInstruments = instruments
+	slog.Info("Exporting OpenTelemetry metrics", "endpoint", config.OtelEndpoint)
+}
+
+// otelMetricsMiddleware erfasst Anzahl und Dauer aller Requests
+func otelMetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if otelInstruments == nil {
+			return
+		}
+
+		attrs := metric.WithAttributes(
+			attribute.String("method", c.Request.Method),
+			attribute.String("route", c.FullPath()),
+			attribute.String("status", strconv.Itoa(c.Writer.Status())))
+		otelInstruments.requests.Add(c.Request.Context(), 1, attrs)
+		otelInstruments.requestDuration.Record(c.Request.Context(), time.Since(start).Seconds(), attrs)
+	}
+}
+
+// recordUpstreamCall zählt einen Aufruf an einen Upstream-Service und hängt
+// ihn an den Trace des Requests an
+func recordUpstreamCall(ctx context.Context, serviceURL, method string, err error) {
+	traceUpstreamCall(ctx, serviceURL, method, err)
+	observeUpstreamCall(serviceURL, method, err)
+	if otelInstruments == nil {
+		return
+	}
+	otelInstruments.upstreamCalls.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("upstream", serviceURL),
+		attribute.String("method", method),
+		attribute.String("outcome", metricsOutcome(err))))
+}
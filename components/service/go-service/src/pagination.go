@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Standardgröße einer Seite, wenn der Client kein limit angibt
+const defaultPageLimit = 100
+
+// requestedLimitOffset liest die Query-Parameter limit (mindestens 1) und
+// offset (mindestens 0)
+func requestedLimitOffset(c *gin.Context) (int, int, error) {
+	limit, err := intQuery(c, "limit", defaultPageLimit, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err := intQuery(c, "offset", 0, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+func intQuery(c *gin.Context, name string, defaultValue, minValue int) (int, error) {
+	valueStr, present := c.GetQuery(name)
+	if !present {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < minValue {
+		return 0, fmt.Errorf("invalid %s %q, expected an integer of at least %d", name, valueStr, minValue)
+	}
+	return value, nil
+}
+
+// pageOf schneidet die Seite aus limit Entitäten ab offset aus dtos aus, etwa
+// für die aggregierten Entitäten der Upstreams
+func pageOf(dtos []BaseDto, limit, offset int) []BaseDto {
+	offset = min(offset, len(dtos))
+	return dtos[offset:min(len(dtos), offset+limit)]
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"log/slog"
+)
+
+// Marker im Feld payloadEncoding für komprimierte Payloads
+const payloadEncodingGzip = "gzip+base64"
+
+// compressEntityPayloads komprimiert Payloads oberhalb von
+// PayloadCompressionThreshold einzeln mit gzip und kodiert sie als Base64.
+// Kleinere Payloads bleiben unverändert, sodass eine Antwort gemischt
+// kodierte Entitäten enthalten kann.
+func compressEntityPayloads(ctx context.Context, dtos []BaseDto) {
+	if config.PayloadCompressionThreshold <= 0 {
+		return
+	}
+	for i := range dtos {
+		if len(dtos[i].Payload) <= config.PayloadCompressionThreshold {
+			continue
+		}
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write([]byte(dtos[i].Payload)); err != nil {
+			slog.WarnContext(ctx, "Konnte Payload nicht komprimieren", "id", dtos[i].ID, "error", err)
+			continue
+		}
+		if err := writer.Close(); err != nil {
+			slog.WarnContext(ctx, "Konnte Payload nicht komprimieren", "id", dtos[i].ID, "error", err)
+			continue
+		}
+		dtos[i].Payload = base64.StdEncoding.EncodeToString(buf.Bytes())
+		dtos[i].PayloadEncoding = payloadEncodingGzip
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerPprof stellt die Profiling-Endpunkte von net/http/pprof unter
+// /debug/pprof bereit, z.B. /debug/pprof/profile?seconds=30 für ein CPU-Profil
+// oder /debug/pprof/heap für den Speicher
+func registerPprof(router *gin.Engine) {
+	debug := router.Group("/debug/pprof")
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
+	// heap, goroutine, allocs, block, mutex und threadcreate
+	debug.GET("/:profile", gin.WrapF(pprof.Index))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strings"
+)
+
+const payloadAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// generatePayload erzeugt die Payload einer Dummy-Entität. Mit RandomPayload
+// besteht sie aus zufälligen alphanumerischen Zeichen, die sich kaum
+// komprimieren lassen. Der Zufall wird aus PayloadSeed und der ID abgeleitet,
+// sodass dieselbe Entität bei jedem Abruf dieselbe Payload erhält.
+func generatePayload(id string, size int) string {
+	if !config.RandomPayload {
+		return strings.Repeat("x", size)
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(id))
+	rng := rand.New(rand.NewSource(config.PayloadSeed ^ int64(hash.Sum64())))
+
+	payload := make([]byte, size)
+	for i := range payload {
+		payload[i] = payloadAlphabet[rng.Intn(len(payloadAlphabet))]
+	}
+	return string(payload)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitPruneInterval ist der Abstand, in dem die Buckets untätiger Clients
+// entfernt werden
+const rateLimitPruneInterval = time.Minute
+
+// rateLimiter verteilt Tokens mit RateLimitRPS. Standardmäßig teilen sich alle
+// Clients einen Bucket, mit RateLimitPerClient erhält jeder Client einen eigenen.
+type rateLimiter struct {
+	mu      sync.Mutex
+	global  *tokenBucket
+	clients map[string]*tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	if config.RateLimitPerClient {
+		limiter := &rateLimiter{clients: map[string]*tokenBucket{}}
+		go func() {
+			for range time.Tick(rateLimitPruneInterval) {
+				limiter.prune()
+			}
+		}()
+		return limiter
+	}
+	return &rateLimiter{global: newTokenBucket(config.RateLimitRPS, float64(config.RateLimitBurst))}
+}
+
+func (l *rateLimiter) bucket(client string) *tokenBucket {
+	if l.global != nil {
+		return l.global
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, ok := l.clients[client]
+	if !ok {
+		bucket = newTokenBucket(config.RateLimitRPS, float64(config.RateLimitBurst))
+		l.clients[client] = bucket
+	}
+	return bucket
+}
+
+// prune entfernt die Buckets untätiger Clients. Ein voller Bucket verhält
+// sich wie ein neuer, daher geht dabei kein Zustand verloren.
+func (l *rateLimiter) prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for client, bucket := range l.clients {
+		if bucket.full() {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// rateLimitMiddleware weist Requests oberhalb von RateLimitRPS (mit
+// RateLimitBurst als Puffer) mit 429 ab. Retry-After nennt die Wartezeit bis
+// zum nächsten freien Token plus Jitter wie bei setRetryAfter.
+func rateLimitMiddleware(limiter *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bucket := limiter.bucket(clientKey(c))
+		if bucket.tryTake() {
+			c.Next()
+			return
+		}
+
+		wait := bucket.nextTokenIn()
+		c.Header("Retry-After", retryAfterSeconds(withRetryJitter(wait)))
+		slog.InfoContext(c.Request.Context(), "Rejecting request, rate limit reached", "method", c.Request.Method, "path", c.Request.URL.Path, "rps", config.RateLimitRPS)
+		abortWithError(c, http.StatusTooManyRequests, "rate limit exceeded")
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Jede Entität liegt als JSON unter base:<id>, das Set enthält alle IDs
+	redisKeyPrefix = "base:"
+	redisIndexKey  = "base_ids"
+)
+
+// redisEntity ist die gespeicherte Form einer Entität. Im Gegensatz zur
+// Antwort enthält sie den IV einer verschlüsselten Payload.
+type redisEntity struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Payload   string     `json:"payload"`
+	Owner     string     `json:"owner,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	PayloadIV string     `json:"payloadIv,omitempty"`
+}
+
+func newRedisClient() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     config.Redis.Addr,
+		Password: config.Redis.Password,
+		DB:       config.Redis.DB,
+	})
+}
+
+func currentRedisClient() *redis.Client {
+	dbLock.RLock()
+	defer dbLock.RUnlock()
+	return redisClient
+}
+
+func redisKey(id string) string {
+	return redisKeyPrefix + id
+}
+
+func encodeRedisEntity(dto BaseDto) ([]byte, error) {
+	return json.Marshal(redisEntity{
+		ID:        dto.ID,
+		Name:      dto.Name,
+		Payload:   dto.Payload,
+		Owner:     dto.Owner,
+		UpdatedAt: dto.UpdatedAt,
+		PayloadIV: dto.PayloadIV,
+	})
+}
+
+func decodeRedisEntity(data string) (BaseDto, error) {
+	var entity redisEntity
+	if err := json.Unmarshal([]byte(data), &entity); err != nil {
+		return BaseDto{}, err
+	}
+	return BaseDto{
+		ID:        entity.ID,
+		Name:      entity.Name,
+		Payload:   entity.Payload,
+		Owner:     entity.Owner,
+		UpdatedAt: entity.UpdatedAt,
+		PayloadIV: entity.PayloadIV,
+	}, nil
+}
+
+// sortedRedisIDs liefert alle IDs sortiert, analog zu ORDER BY id
+func sortedRedisIDs(ctx context.Context) ([]string, error) {
+	ids, err := currentRedisClient().SMembers(ctx, redisIndexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// loadRedisEntities liest die Entitäten zu den IDs. Zwischenzeitlich gelöschte
+// Entitäten werden übersprungen.
+func loadRedisEntities(ctx context.Context, ids []string) ([]BaseDto, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisKey(id)
+	}
+	values, err := currentRedisClient().MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var dtos []BaseDto
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		dto, err := decodeRedisEntity(data)
+		if err != nil {
+			return nil, err
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos, nil
+}
+
+func countInRedis(ctx context.Context) (int64, error) {
+	return currentRedisClient().SCard(ctx, redisIndexKey).Result()
+}
+
+func getAllFromRedis(ctx context.Context, limit, offset int) ([]BaseDto, error) {
+	ids, err := sortedRedisIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids = ids[min(offset, len(ids)):]
+	if limit > 0 {
+		ids = ids[:min(limit, len(ids))]
+	}
+	return loadRedisEntities(ctx, ids)
+}
+
+func getPageFromRedis(ctx context.Context, afterID string, limit int) ([]BaseDto, error) {
+	ids, err := sortedRedisIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := sort.Search(len(ids), func(i int) bool { return ids[i] > afterID })
+	ids = ids[start:]
+	return loadRedisEntities(ctx, ids[:min(limit, len(ids))])
+}
+
+func getUpdatedSinceFromRedis(ctx context.Context, since time.Time) ([]BaseDto, error) {
+	dtos, err := getAllFromRedis(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filterUpdatedSince(dtos, since), nil
+}
+
+func getOneFromRedis(ctx context.Context, id string) (BaseDto, bool, error) {
+	data, err := currentRedisClient().Get(ctx, redisKey(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return BaseDto{}, false, nil
+	}
+	if err != nil {
+		return BaseDto{}, false, err
+	}
+	dto, err := decodeRedisEntity(data)
+	return dto, err == nil, err
+}
+
+func saveToRedis(ctx context.Context, dto BaseDto) (BaseDto, error) {
+	observePayloadSize("stored", dto)
+	data, err := encodeRedisEntity(dto)
+	if err != nil {
+		return BaseDto{}, err
+	}
+	_, err = currentRedisClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, redisKey(dto.ID), data, 0)
+		pipe.SAdd(ctx, redisIndexKey, dto.ID)
+		return nil
+	})
+	return dto, err
+}
+
+// saveAllToRedis speichert alle Entitäten in einer Transaktion
+func saveAllToRedis(ctx context.Context, dtos []BaseDto) error {
+	_, err := currentRedisClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, dto := range dtos {
+			observePayloadSize("stored", dto)
+			data, err := encodeRedisEntity(dto)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, redisKey(dto.ID), data, 0)
+			pipe.SAdd(ctx, redisIndexKey, dto.ID)
+		}
+		return nil
+	})
+	return err
+}
+
+// updateInRedis überschreibt die Entität nur, wenn sie bereits vorhanden ist
+func updateInRedis(ctx context.Context, dto BaseDto) (bool, error) {
+	observePayloadSize("stored", dto)
+	data, err := encodeRedisEntity(dto)
+	if err != nil {
+		return false, err
+	}
+	return currentRedisClient().SetXX(ctx, redisKey(dto.ID), data, 0).Result()
+}
+
+func deleteFromRedis(ctx context.Context, id string) (bool, error) {
+	var deleted *redis.IntCmd
+	_, err := currentRedisClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Del(ctx, redisKey(id))
+		pipe.SRem(ctx, redisIndexKey, id)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted.Val() > 0, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// relevanceScore zählt die Vorkommen des Suchbegriffs in name und payload,
+// ohne Groß-/Kleinschreibung zu beachten
+func relevanceScore(dto BaseDto, query string) int {
+	return strings.Count(strings.ToLower(dto.Name), query) +
+		strings.Count(strings.ToLower(dto.Payload), query)
+}
+
+// rankByRelevance liefert nur die Entitäten, die den Suchbegriff enthalten,
+// absteigend sortiert nach ihrem Score. Ohne Suchbegriff bleibt die Liste
+// unverändert.
+func rankByRelevance(dtos []BaseDto, query string) []BaseDto {
+	if query == "" {
+		return dtos
+	}
+	query = strings.ToLower(query)
+
+	type scoredDto struct {
+		dto   BaseDto
+		score int
+	}
+	var scored []scoredDto
+	for _, dto := range dtos {
+		if score := relevanceScore(dto, query); score > 0 {
+			scored = append(scored, scoredDto{dto, score})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	ranked := make([]BaseDto, 0, len(scored))
+	for _, entry := range scored {
+		ranked = append(ranked, entry.dto)
+	}
+	return ranked
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+// Längere IDs werden verworfen, damit Clients keine beliebigen Daten in die
+// Logs schreiben können
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// requestIDMiddleware übernimmt die X-Request-Id des Clients oder erzeugt eine
+// neue UUID. Die ID wird im Kontext des Requests abgelegt, in jeder Log-Zeile
+// ausgegeben, an die Upstream-Services weitergegeben und zurückgeschickt.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newUUID()
+		}
+		c.Set("requestId", id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// newUUID erzeugt eine zufällige UUID (Version 4)
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDHandler ergänzt jede Log-Zeile, deren Kontext eine Request-ID
+// trägt, um das Feld requestId und bei authentifizierten Requests um subject
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("requestId", id))
+	}
+	if subject := jwtSubjectFromContext(ctx); subject != "" {
+		record.AddAttrs(slog.String("subject", subject))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
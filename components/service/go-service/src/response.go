@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedResponseWriter puffert den Response-Body, damit Middlewares ihn nach
+// dem Handler noch auswerten können, bevor er an den Client geht.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func newBufferedResponseWriter(w gin.ResponseWriter) *bufferedResponseWriter {
+	return &bufferedResponseWriter{ResponseWriter: w, body: &bytes.Buffer{}}
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// flush schreibt den gepufferten Body in den ursprünglichen Writer
+func (w *bufferedResponseWriter) flush() {
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// upstreamStatusError ist eine Antwort eines Upstreams mit Fehlerstatus
+type upstreamStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *upstreamStatusError) Error() string {
+	return "unexpected status " + e.Status
+}
+
+// isRetryable erlaubt erneute Versuche nur bei Verbindungsfehlern und 5xx
+func isRetryable(err error) bool {
+	var statusErr *upstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryBackoff liefert die Wartezeit vor dem erneuten Versuch attempt (ab 1):
+// UpstreamRetryBackoff verdoppelt je Versuch plus zufälligem Jitter bis zur Basis
+func retryBackoff(attempt int) time.Duration {
+	base := config.UpstreamRetryBackoff
+	if base <= 0 {
+		return 0
+	}
+	return base<<(attempt-1) + time.Duration(rand.Int63n(int64(base)))
+}
+
+// withRetries wiederholt call bis zu UpstreamRetries Mal mit exponentiellem
+// Backoff. Ein Versuch entfällt, wenn er die Deadline des Kontexts
+// überschreiten würde oder das Retry-Budget des Upstreams erschöpft ist.
+func withRetries[T any](ctx context.Context, serviceURL string, call func() (T, error)) (T, error) {
+	result, err := call()
+	for attempt := 1; attempt <= config.UpstreamRetries && err != nil && isRetryable(err); attempt++ {
+		backoff := retryBackoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+			slog.WarnContext(ctx, "Kein weiterer Versuch, die Deadline wäre überschritten", "upstream", serviceURL)
+			break
+		}
+		if !allowRetry(ctx, serviceURL) {
+			break
+		}
+
+		slog.WarnContext(ctx, "Upstream-Aufruf fehlgeschlagen, neuer Versuch", "upstream", serviceURL, "error", err,
+			"attempt", attempt, "retries", config.UpstreamRetries, "backoff", backoff.String())
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return result, err
+		}
+		result, err = call()
+	}
+	return result, err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setRetryAfter setzt bei 429 und 503 einen Retry-After-Header aus
+// RetryAfterBase plus zufälligem Jitter bis RetryAfterJitter, damit Clients
+// nach einer Erholung nicht gleichzeitig erneut anfragen. Ein bereits
+// gesetzter Header (z.B. das Ende eines Wartungsfensters) bleibt erhalten.
+func setRetryAfter(c *gin.Context, status int) {
+	if status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		return
+	}
+	if config.RetryAfterBase <= 0 && config.RetryAfterJitter <= 0 {
+		return
+	}
+	if c.Writer.Header().Get("Retry-After") != "" {
+		return
+	}
+
+	c.Header("Retry-After", retryAfterSeconds(withRetryJitter(config.RetryAfterBase)))
+}
+
+// withRetryJitter verlängert wait um zufälligen Jitter bis RetryAfterJitter
+func withRetryJitter(wait time.Duration) time.Duration {
+	if config.RetryAfterJitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(config.RetryAfterJitter) + 1))
+	}
+	return wait
+}
+
+// retryAfterSeconds formatiert wait als Retry-After in ganzen Sekunden,
+// mindestens 1
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+var (
+	retryBudgetLock sync.Mutex
+	retryBudgets    = map[string]*tokenBucket{}
+)
+
+// allowRetry entnimmt dem Retry-Budget des Upstreams ein Token. Ist das Budget
+// aufgebraucht, wird nicht erneut versucht, damit anhaltende Fehler keine
+// Retry-Stürme auslösen. Ohne RetryBudgetSize sind Retries unbegrenzt.
+func allowRetry(ctx context.Context, serviceURL string) bool {
+	if config.RetryBudgetSize <= 0 {
+		return true
+	}
+
+	retryBudgetLock.Lock()
+	budget, ok := retryBudgets[serviceURL]
+	if !ok {
+		budget = newTokenBucket(config.RetryBudgetRefillPerSec, float64(config.RetryBudgetSize))
+		retryBudgets[serviceURL] = budget
+	}
+	retryBudgetLock.Unlock()
+
+	if !budget.tryTake() {
+		slog.WarnContext(ctx, "Retry-Budget erschöpft, kein erneuter Versuch", "upstream", serviceURL)
+		return false
+	}
+	return true
+}
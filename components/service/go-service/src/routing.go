@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpstreamRoute leitet Requests mit einem bestimmten Query-Parameter-Wert an
+// einen festgelegten Upstream-Service weiter (z.B. ?region=eu).
+type UpstreamRoute struct {
+	Param string
+	Value string
+	URL   string
+}
+
+// parseUpstreamRoutes liest Routen im Format "param:wert=url", mehrere Routen
+// werden durch Kommas getrennt.
+func parseUpstreamRoutes(routesStr string) []UpstreamRoute {
+	var routes []UpstreamRoute
+	for _, routeStr := range strings.Split(routesStr, ",") {
+		routeStr = strings.TrimSpace(routeStr)
+		if routeStr == "" {
+			continue
+		}
+		condition, url, found := strings.Cut(routeStr, "=")
+		param, value, hasValue := strings.Cut(condition, ":")
+		if !found || !hasValue || param == "" || url == "" {
+			slog.Warn("Ungültige Upstream-Route wird ignoriert", "route", routeStr)
+			continue
+		}
+		routes = append(routes, UpstreamRoute{Param: param, Value: value, URL: url})
+	}
+	return routes
+}
+
+// selectUpstreams wählt die Upstream-Services anhand der Query-Parameter des
+// Requests. Passt keine Route, werden die konfigurierten Upstreams gemäß
+// UpstreamMode verwendet.
+func selectUpstreams(c *gin.Context) []string {
+	return routeUpstreams(c.Request.Context(), c.GetQuery)
+}
+
+// routeUpstreams wählt die Upstream-Services anhand der Parameter, die param
+// liefert. Über HTTP sind das Query-Parameter, über gRPC Metadaten.
+func routeUpstreams(ctx context.Context, param func(name string) (string, bool)) []string {
+	var selected []string
+	for _, route := range config.UpstreamRoutes {
+		if value, ok := param(route.Param); ok && value == route.Value {
+			selected = append(selected, route.URL)
+		}
+	}
+	if len(selected) > 0 {
+		slog.InfoContext(ctx, "Routing request based on query parameters", "upstreams", selected)
+		return selected
+	}
+	return configuredUpstreams()
+}
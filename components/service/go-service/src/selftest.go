@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SelftestStep beschreibt einen Schritt der synthetischen Transaktion
+type SelftestStep struct {
+	Name       string  `json:"name"`
+	Success    bool    `json:"success"`
+	DurationMs float64 `json:"durationMs"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SelftestResult ist die Antwort von /actuator/selftest
+type SelftestResult struct {
+	Backend string         `json:"backend"`
+	Success bool           `json:"success"`
+	Steps   []SelftestStep `json:"steps"`
+}
+
+func runSelftestStep(result *SelftestResult, name string, step func() error) bool {
+	start := time.Now()
+	err := step()
+	entry := SelftestStep{
+		Name:       name,
+		Success:    err == nil,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		result.Success = false
+	}
+	result.Steps = append(result.Steps, entry)
+	return err == nil
+}
+
+// selftest schreibt eine Wegwerf-Entität in das konfigurierte Backend und
+// liest sie wieder aus, danach wird sie gelöscht. Zeit und Erfolg werden pro Schritt zurückgegeben.
+func selftest(c *gin.Context) {
+	result := SelftestResult{Backend: dbBackendName(), Success: true, Steps: []SelftestStep{}}
+	if !isDBActive() {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	ctx := c.Request.Context()
+	probe := BaseDto{
+		ID:      fmt.Sprintf("selftest-%d", time.Now().UnixNano()),
+		Name:    "Selftest Entity",
+		Payload: "selftest",
+	}
+
+	written := runSelftestStep(&result, "write", func() error {
+		_, err := saveToDB(ctx, probe)
+		return err
+	})
+	if written {
+		runSelftestStep(&result, "read", func() error {
+			dto, found, err := getOneFromDB(ctx, probe.ID)
+			switch {
+			case err != nil:
+				return err
+			case !found:
+				return fmt.Errorf("entity %s not found after write", probe.ID)
+			case dto.Payload != probe.Payload:
+				return fmt.Errorf("entity %s read back with different payload", probe.ID)
+			}
+			return nil
+		})
+
+		// Die Wegwerf-Entität wird immer wieder entfernt, auch wenn das Lesen scheiterte
+		runSelftestStep(&result, "delete", func() error {
+			defer listCache.invalidate()
+			found, err := deleteFromDB(ctx, probe.ID)
+			switch {
+			case err != nil:
+				return err
+			case !found:
+				return fmt.Errorf("entity %s not found on delete", probe.ID)
+			}
+			return nil
+		})
+	}
+
+	status := http.StatusOK
+	if !result.Success {
+		slog.WarnContext(c.Request.Context(), "Selftest fehlgeschlagen", "backend", result.Backend, "steps", result.Steps)
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serverTimingWriter setzt vor dem ersten Schreiben der Antwort einen
+// Server-Timing-Header mit der Wartezeit auf einen Slot (queue) und der
+// bisherigen Bearbeitungszeit (service)
+type serverTimingWriter struct {
+	gin.ResponseWriter
+	queue   time.Duration
+	started time.Time
+	written bool
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.written {
+		return
+	}
+	w.written = true
+	w.ResponseWriter.Header().Add("Server-Timing", fmt.Sprintf("queue;dur=%.3f, service;dur=%.3f",
+		float64(w.queue.Microseconds())/1000, float64(time.Since(w.started).Microseconds())/1000))
+}
+
+func (w *serverTimingWriter) WriteHeader(code int) {
+	w.setHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *serverTimingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *serverTimingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *serverTimingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	shutdownModeDrainServing   = "drain-serving"
+	shutdownModeDrainRejecting = "drain-rejecting"
+)
+
+// runServer startet den HTTP-Server und fährt ihn bei SIGINT/SIGTERM herunter.
+// Im Modus drain-serving werden während ShutdownDelay weiter neue Requests
+// angenommen, im Modus drain-rejecting wird der Listener sofort geschlossen
+// und nur noch die laufenden Requests werden beendet. Laufende Requests
+// erhalten höchstens ShutdownGracePeriod, danach werden die Verbindungen
+// geschlossen. Anschließend wird der gRPC-Server auf dieselbe Weise beendet.
+// Zuletzt werden Kafka-Consumer, Datenbankverbindung und
+// Kafka-Producer getrennt.
+func runServer(server *http.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-signals
+		slog.Info("Shutting down", "signal", sig.String(), "mode", config.ShutdownMode)
+
+		// Health meldet ab jetzt DOWN, damit der Load Balancer die Instanz austrägt
+		serviceReady.Store(false)
+		if config.ShutdownMode == shutdownModeDrainServing && config.ShutdownDelay > 0 {
+			slog.Info("Serving new requests until shutdown delay has passed", "delay", config.ShutdownDelay.String())
+			time.Sleep(config.ShutdownDelay)
+		}
+
+		slog.Info("Draining in-flight requests", "gracePeriod", config.ShutdownGracePeriod.String())
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownGracePeriod)
+		err := server.Shutdown(ctx)
+		cancel()
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			slog.Warn("Grace Period abgelaufen, schließe verbleibende Verbindungen", "elapsed", time.Since(start).String())
+			server.Close()
+		case err != nil:
+			slog.Warn("Server konnte nicht sauber beendet werden", "error", err)
+		default:
+			slog.Info("In-flight requests drained", "elapsed", time.Since(start).String())
+		}
+
+		stopGRPCServer()
+		stopKafkaConsumer()
+		closeDB()
+		closeKafka()
+		slog.Info("Shutdown complete")
+		close(stopped)
+	}()
+
+	listener, err := newListener(server.Addr)
+	if err != nil {
+		slog.Error("Konnte Server nicht starten", "error", err)
+		os.Exit(1)
+	}
+	if err := serve(server, listener); err != nil && err != http.ErrServerClosed {
+		slog.Error("Konnte Server nicht starten", "error", err)
+		os.Exit(1)
+	}
+	<-stopped
+}
+
+// serve bedient den Listener per HTTPS, wenn Zertifikat und Schlüssel
+// konfiguriert sind, sonst per HTTP
+func serve(server *http.Server, listener net.Listener) error {
+	if config.TLSCertFile != "" {
+		slog.Info("Serving HTTPS", "certFile", config.TLSCertFile)
+		return server.ServeTLS(listener, config.TLSCertFile, config.TLSKeyFile)
+	}
+	return server.Serve(listener)
+}
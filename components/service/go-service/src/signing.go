@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signatureHeader enthält die HMAC-SHA256-Signatur des Response-Bodys
+const signatureHeader = "X-Signature"
+
+func isSigningEnabled() bool {
+	return config.HmacSecret != ""
+}
+
+// signBody berechnet die hex-kodierte HMAC-SHA256-Signatur über den Body
+func signBody(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(config.HmacSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature prüft die Signatur, die ein Upstream-Service mit seiner
+// Antwort geschickt hat. Der Vergleich erfolgt in konstanter Zeit.
+func verifySignature(body []byte, signature string) bool {
+	return hmac.Equal([]byte(signBody(body)), []byte(signature))
+}
+
+// signatureMiddleware puffert die Antwort und setzt den X-Signature-Header,
+// bevor der Body an den Client geschrieben wird.
+func signatureMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := newBufferedResponseWriter(c.Writer)
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		c.Header(signatureHeader, signBody(writer.body.Bytes()))
+		writer.flush()
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+const (
+	dbDriverPostgres = "postgres"
+	dbDriverMySQL    = "mysql"
+)
+
+// Die Abfragen sind mit PostgreSQL-Platzhaltern ($1, $2, ...) geschrieben und
+// werden für MySQL mit rebind umgeschrieben. Die Platzhalter müssen dafür in
+// aufsteigender Reihenfolge und jeweils nur einmal vorkommen.
+var postgresPlaceholder = regexp.MustCompile(`\$\d+`)
+
+func isMySQL() bool {
+	return config.DBDriver == dbDriverMySQL
+}
+
+func sqlDSN() string {
+	if isMySQL() {
+		return mysqlDSN()
+	}
+	return postgresDSN()
+}
+
+func postgresDSN() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		config.Datasource.Username, config.Datasource.Password,
+		config.Datasource.Host, config.Datasource.Port, config.Datasource.DBName)
+}
+
+// mysqlDSN liefert die DSN für go-sql-driver/mysql. Mit parseTime werden
+// DATETIME-Spalten als time.Time gelesen.
+func mysqlDSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		config.Datasource.Username, config.Datasource.Password,
+		config.Datasource.Host, config.Datasource.Port, config.Datasource.DBName)
+}
+
+// rebind passt die Platzhalter einer Abfrage an den konfigurierten Treiber an
+func rebind(query string) string {
+	if !isMySQL() {
+		return query
+	}
+	return postgresPlaceholder.ReplaceAllString(query, "?")
+}
+
+// upsertClause liefert die Klausel, mit der ein INSERT bei vorhandenem
+// Schlüssel die übrigen Spalten überschreibt
+func upsertClause(keyColumn string, columns ...string) string {
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		if isMySQL() {
+			assignments[i] = fmt.Sprintf("%s = VALUES(%s)", column, column)
+		} else {
+			assignments[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+		}
+	}
+	if isMySQL() {
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", keyColumn, strings.Join(assignments, ", "))
+}
+
+// ignoreConflictClause liefert die Klausel, mit der ein INSERT bei vorhandenem
+// Schlüssel nichts ändert. Ob eingefügt wurde, zeigt RowsAffected.
+func ignoreConflictClause(keyColumn string) string {
+	if isMySQL() {
+		return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s = %s", keyColumn, keyColumn)
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", keyColumn)
+}
+
+// quoteIdent maskiert Spaltennamen, die in MySQL reservierte Wörter sind
+func quoteIdent(name string) string {
+	if isMySQL() {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
+
+// timestampType ist der Spaltentyp für Zeitpunkte. MySQL speichert DATETIME
+// ohne Zeitzone, der Service schreibt ausschließlich UTC.
+func timestampType() string {
+	if isMySQL() {
+		return "DATETIME(6)"
+	}
+	return "TIMESTAMP WITH TIME ZONE"
+}
+
+// textType ist der Spaltentyp für Payloads; TEXT ist in MySQL auf 64 KB begrenzt
+func textType() string {
+	if isMySQL() {
+		return "LONGTEXT"
+	}
+	return "TEXT"
+}
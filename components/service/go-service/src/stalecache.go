@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// staleEntityCache merkt sich die zuletzt erfolgreich gelesene oder
+// geschriebene Fassung jeder Entität, um sie bei Datenbankfehlern als veraltete
+// Antwort auszuliefern
+type staleEntityCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedEntity
+}
+
+type cachedEntity struct {
+	dto     BaseDto
+	fetched time.Time
+}
+
+var staleEntities = &staleEntityCache{entries: map[string]cachedEntity{}}
+
+func (s *staleEntityCache) put(dto BaseDto) {
+	if config.StaleIfError <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[dto.ID] = cachedEntity{dto: dto, fetched: time.Now()}
+}
+
+// get liefert den gecachten Eintrag, solange er nicht älter als StaleIfError ist
+func (s *staleEntityCache) get(id string) (cachedEntity, bool) {
+	if config.StaleIfError <= 0 {
+		return cachedEntity{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[id]
+	if !ok || time.Since(entry.fetched) > config.StaleIfError {
+		return cachedEntity{}, false
+	}
+	return entry, true
+}
+
+// remove entfernt eine gelöschte Entität aus dem Cache
+func (s *staleEntityCache) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+}
+
+// markStale kennzeichnet eine Antwort als veraltet (RFC 7234 Warning 110)
+func markStale(c *gin.Context, entry cachedEntity) {
+	c.Header("Warning", `110 - "Response is Stale"`)
+	c.Header("Age", fmt.Sprintf("%d", int(time.Since(entry.fetched).Seconds())))
+}
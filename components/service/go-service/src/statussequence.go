@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Schlüssel der Sequenz, die für alle Clients ohne eigene Sequenz gilt
+const defaultSequenceClient = "*"
+
+var (
+	sequenceLock      sync.Mutex
+	sequencePositions = map[string]int{}
+)
+
+// parseStatusSequences liest Statussequenzen im Format
+// "200,500,503;client-a=500,500,200". Eine Sequenz ohne Client gilt für alle
+// Clients ohne eigene Sequenz.
+func parseStatusSequences(sequencesStr string) map[string][]int {
+	sequences := map[string][]int{}
+	for _, sequenceStr := range strings.Split(sequencesStr, ";") {
+		sequenceStr = strings.TrimSpace(sequenceStr)
+		if sequenceStr == "" {
+			continue
+		}
+		client, codesStr, found := strings.Cut(sequenceStr, "=")
+		if !found {
+			client, codesStr = defaultSequenceClient, sequenceStr
+		}
+		client = strings.TrimSpace(client)
+
+		var statuses []int
+		for _, statusStr := range strings.Split(codesStr, ",") {
+			status, err := strconv.Atoi(strings.TrimSpace(statusStr))
+			if err != nil || http.StatusText(status) == "" {
+				slog.Warn("Ungültiger Statuscode in der Statussequenz", "status", statusStr, "client", client)
+				continue
+			}
+			statuses = append(statuses, status)
+		}
+		if len(statuses) > 0 {
+			sequences[client] = statuses
+		}
+	}
+	return sequences
+}
+
+// nextSequencedStatus liefert den nächsten Statuscode aus der Sequenz des
+// Clients. Jeder Client durchläuft seine Sequenz unabhängig und beginnt nach
+// dem letzten Eintrag wieder von vorn.
+func nextSequencedStatus(client string) (int, bool) {
+	statuses, ok := config.StatusSequences[client]
+	if !ok {
+		statuses, ok = config.StatusSequences[defaultSequenceClient]
+	}
+	if !ok {
+		return 0, false
+	}
+
+	sequenceLock.Lock()
+	defer sequenceLock.Unlock()
+	position := sequencePositions[client]
+	sequencePositions[client] = (position + 1) % len(statuses)
+	return statuses[position], true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpstreamCallTrace beschreibt einen Upstream-Aufruf innerhalb eines Requests
+type UpstreamCallTrace struct {
+	Upstream string `json:"upstream"`
+	Method   string `json:"method"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RequestTrace ist ein Eintrag im Trace-Puffer
+type RequestTrace struct {
+	Timestamp     time.Time           `json:"timestamp"`
+	Method        string              `json:"method"`
+	Path          string              `json:"path"`
+	Status        int                 `json:"status"`
+	LatencyMs     float64             `json:"latencyMs"`
+	UpstreamCalls []UpstreamCallTrace `json:"upstreamCalls"`
+}
+
+type traceContextKey struct{}
+
+// activeTrace sammelt die Upstream-Aufrufe eines laufenden Requests
+type activeTrace struct {
+	mu    sync.Mutex
+	calls []UpstreamCallTrace
+}
+
+// traceBuffer ist ein Ringpuffer der letzten TraceBufferSize Requests
+type traceBuffer struct {
+	mu      sync.Mutex
+	entries []RequestTrace
+	next    int
+}
+
+var traces *traceBuffer
+
+func (b *traceBuffer) add(trace RequestTrace) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, trace)
+		return
+	}
+	b.entries[b.next] = trace
+	b.next = (b.next + 1) % len(b.entries)
+}
+
+// recent liefert die Einträge, den neuesten zuerst
+func (b *traceBuffer) recent() []RequestTrace {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]RequestTrace, 0, len(b.entries))
+	for i := len(b.entries) - 1; i >= 0; i-- {
+		result = append(result, b.entries[(b.next+i)%len(b.entries)])
+	}
+	return result
+}
+
+// traceMiddleware zeichnet jeden Request im Trace-Puffer auf
+func traceMiddleware() gin.HandlerFunc {
+	traces = &traceBuffer{entries: make([]RequestTrace, 0, config.TraceBufferSize)}
+	return func(c *gin.Context) {
+		start := time.Now()
+		trace := &activeTrace{}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), traceContextKey{}, trace))
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		trace.mu.Lock()
+		calls := append([]UpstreamCallTrace{}, trace.calls...)
+		trace.mu.Unlock()
+		traces.add(RequestTrace{
+			Timestamp:     start,
+			Method:        c.Request.Method,
+			Path:          path,
+			Status:        c.Writer.Status(),
+			LatencyMs:     float64(time.Since(start).Microseconds()) / 1000,
+			UpstreamCalls: calls,
+		})
+	}
+}
+
+// traceUpstreamCall hängt einen Upstream-Aufruf an den Trace des Requests an
+func traceUpstreamCall(ctx context.Context, serviceURL, method string, err error) {
+	trace, ok := ctx.Value(traceContextKey{}).(*activeTrace)
+	if !ok {
+		return
+	}
+	call := UpstreamCallTrace{Upstream: serviceURL, Method: method}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	trace.mu.Lock()
+	trace.calls = append(trace.calls, call)
+	trace.mu.Unlock()
+}
+
+// getTraces liefert die zuletzt aufgezeichneten Requests
+func getTraces(c *gin.Context) {
+	c.JSON(http.StatusOK, traces.recent())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer liefert ohne konfigurierten TracingEndpoint No-op-Spans, sodass die
+// Instrumentierung nicht abgeschaltet werden muss
+var tracer = otel.Tracer("microzoo/go-service")
+
+// initOtelTracing richtet den OTLP-Export der Traces an den Collector unter
+// TracingEndpoint ein. Der traceparent-Header eingehender Requests wird
+// übernommen, sodass alle Services einer Kette denselben Trace verwenden.
+func initOtelTracing() {
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpoint(config.TracingEndpoint),
+		otlptracehttp.WithInsecure())
+	if err != nil {
+		slog.Warn("Konnte OTLP-Trace-Exporter nicht erstellen, Traces werden nicht exportiert", "error", err)
+		return
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.TracingSampleRatio))))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	slog.Info("Exporting OpenTelemetry traces", "endpoint", config.TracingEndpoint, "sampleRatio", config.TracingSampleRatio)
+}
+
+// otelTracingMiddleware startet für jeden Request einen Server-Span, der an
+// einen per traceparent übergebenen Trace anschließt
+func otelTracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		spanName := c.Request.Method
+		if route := c.FullPath(); route != "" {
+			spanName += " " + route
+		}
+		ctx, span := tracer.Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", c.Request.Method),
+				attribute.String("http.route", c.FullPath()),
+				attribute.String("http.target", c.Request.URL.RequestURI())))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.String("http.status_code", strconv.Itoa(status)))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// startUpstreamSpan startet einen Client-Span für einen Upstream-Aufruf und
+// überträgt den Trace-Kontext per traceparent-Header an den Upstream
+func startUpstreamSpan(req *http.Request) (*http.Request, trace.Span) {
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", req.URL.String())))
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req, span
+}
+
+// startDBSpan startet einen Kind-Span für eine Datenbankoperation
+func startDBSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "db "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", dbBackendName()),
+			attribute.String("db.operation", operation)))
+}
+
+// endSpan beendet den Span und markiert ihn bei einem Fehler als fehlgeschlagen
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// parseFieldMappings liest Umbenennungsregeln je Upstream im Format
+// "http://svc-a:8080=title>name,body>payload;http://svc-b:8080=label>name"
+func parseFieldMappings(mappingsStr string) map[string]map[string]string {
+	mappings := map[string]map[string]string{}
+	for _, upstreamStr := range strings.Split(mappingsStr, ";") {
+		upstreamStr = strings.TrimSpace(upstreamStr)
+		if upstreamStr == "" {
+			continue
+		}
+		serviceURL, rulesStr, found := strings.Cut(upstreamStr, "=")
+		if !found || serviceURL == "" {
+			slog.Warn("Ungültige Feldzuordnung wird ignoriert", "mapping", upstreamStr)
+			continue
+		}
+
+		rules := map[string]string{}
+		for _, ruleStr := range strings.Split(rulesStr, ",") {
+			from, to, found := strings.Cut(strings.TrimSpace(ruleStr), ">")
+			if !found || from == "" || to == "" {
+				slog.Warn("Ungültige Regel in der Feldzuordnung", "rule", ruleStr, "upstream", serviceURL)
+				continue
+			}
+			rules[from] = to
+		}
+		mappings[strings.TrimSuffix(serviceURL, "/")] = rules
+	}
+	return mappings
+}
+
+// decodeUpstreamEntities wandelt die Antwort eines Upstream-Services in
+// BaseDtos um. Sind für den Upstream Feldzuordnungen konfiguriert, werden die
+// Felder vorher umbenannt, sodass abweichende Schemata auf BaseDto passen.
+func decodeUpstreamEntities(serviceURL string, body []byte) ([]BaseDto, error) {
+	rules, ok := config.UpstreamFieldMappings[strings.TrimSuffix(serviceURL, "/")]
+	if !ok {
+		var dtos []BaseDto
+		err := json.Unmarshal(body, &dtos)
+		return dtos, err
+	}
+
+	var raw []map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	for _, entity := range raw {
+		applyFieldMapping(entity, rules)
+	}
+
+	mapped, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var dtos []BaseDto
+	err = json.Unmarshal(mapped, &dtos)
+	return dtos, err
+}
+
+func applyFieldMapping(entity map[string]any, rules map[string]string) {
+	for from, to := range rules {
+		if value, ok := entity[from]; ok {
+			delete(entity, from)
+			entity[to] = value
+		}
+	}
+}
+
+// RequestTransform beschreibt, wie create eine Entität vor der Weitergabe an
+// die Upstream-Services verändert
+type RequestTransform struct {
+	NamePrefix   string
+	RegenerateID bool
+}
+
+// parseRequestTransform liest die Transformation im Format
+// "namePrefix=gw-,regenerateId"
+func parseRequestTransform(transformStr string) RequestTransform {
+	var transform RequestTransform
+	for _, ruleStr := range strings.Split(transformStr, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(ruleStr), "=")
+		switch name {
+		case "":
+		case "namePrefix":
+			transform.NamePrefix = value
+		case "regenerateId":
+			transform.RegenerateID = true
+		default:
+			slog.Warn("Unbekannte Transformation wird ignoriert", "rule", ruleStr)
+		}
+	}
+	return transform
+}
+
+// applyRequestTransform verändert die Entität wie ein API-Gateway, bevor sie
+// an die Upstream-Services übergeben wird
+func applyRequestTransform(dto *BaseDto) {
+	transform := config.RequestTransform
+	if transform.RegenerateID {
+		dto.ID = fmt.Sprintf("go-%d", time.Now().UnixNano())
+	}
+	dto.Name = transform.NamePrefix + dto.Name
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// upstreamClient wird von allen Upstream-Aufrufen geteilt, damit
+// Verbindungen wiederverwendet werden
+var upstreamClient *http.Client
+
+func newUpstreamClient() *http.Client {
+	return &http.Client{Timeout: config.UpstreamTimeout}
+}
+
+// upstreamError benennt den Upstream-Service, dessen Aufruf fehlgeschlagen ist
+type upstreamError struct {
+	URL string
+	Err error
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("upstream service %s failed: %v", e.URL, e.Err)
+}
+
+func (e *upstreamError) Unwrap() error {
+	return e.Err
+}
+
+// upstreamFailureStatus bildet einen fehlgeschlagenen Upstream-Aufruf auf den
+// Status der eigenen Antwort ab: 504 bei einer Zeitüberschreitung, sonst 502
+func upstreamFailureStatus(err error) int {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// fetchFromUpstream holt die Entitäten eines Upstream-Services über
+// GET %s/api/base
+func fetchFromUpstream(ctx context.Context, serviceURL string) ([]BaseDto, error) {
+	slog.InfoContext(ctx, "Delegating call to upstream", "upstream", serviceURL)
+	dtos, err := withRetries(ctx, serviceURL, func() ([]BaseDto, error) {
+		return throughBreaker(ctx, serviceURL, func() ([]BaseDto, error) {
+			dtos, err := getFromUpstream(ctx, serviceURL)
+			recordUpstreamCall(ctx, serviceURL, http.MethodGet, err)
+			return dtos, err
+		})
+	})
+	if err != nil {
+		return nil, &upstreamError{URL: serviceURL, Err: err}
+	}
+	return dtos, nil
+}
+
+func getFromUpstream(ctx context.Context, serviceURL string) ([]BaseDto, error) {
+	if err := injectUpstreamDNSFailure(ctx, serviceURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(serviceURL, "/")+"/api/base", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	body, err := doUpstreamRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUpstreamEntities(serviceURL, body)
+}
+
+// doUpstreamRequest führt den Request aus und liefert den Body einer
+// erfolgreichen (2xx) Antwort. Ist Signierung aktiv, muss die Antwort eine
+// gültige Signatur tragen.
+func doUpstreamRequest(req *http.Request) ([]byte, error) {
+	if id := requestIDFromContext(req.Context()); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+	req, span := startUpstreamSpan(req)
+	body, err := readUpstreamResponse(req)
+	endSpan(span, err)
+	return body, err
+}
+
+func readUpstreamResponse(req *http.Request) ([]byte, error) {
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &upstreamStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if isSigningEnabled() && !verifySignature(body, resp.Header.Get(signatureHeader)) {
+		return nil, fmt.Errorf("invalid %s header", signatureHeader)
+	}
+	return body, nil
+}
+
+// postToUpstream übergibt eine Entität per POST %s/api/base an einen
+// Upstream-Service und liefert die von ihm zurückgegebene Entität
+func postToUpstream(ctx context.Context, serviceURL string, dto BaseDto) (BaseDto, error) {
+	slog.InfoContext(ctx, "Posting entity to upstream", "id", dto.ID, "upstream", serviceURL)
+	return writeToUpstream(ctx, http.MethodPost, serviceURL, "/api/base", dto)
+}
+
+// putToUpstream ersetzt eine Entität per PUT %s/api/base/:id bei einem
+// Upstream-Service. Kennt der Upstream die Entität nicht, ist der Fehler ein
+// upstreamStatusError mit Status 404.
+func putToUpstream(ctx context.Context, serviceURL string, dto BaseDto) (BaseDto, error) {
+	slog.InfoContext(ctx, "Putting entity to upstream", "id", dto.ID, "upstream", serviceURL)
+	return writeToUpstream(ctx, http.MethodPut, serviceURL, "/api/base/"+url.PathEscape(dto.ID), dto)
+}
+
+func writeToUpstream(ctx context.Context, method, serviceURL, path string, dto BaseDto) (BaseDto, error) {
+	result, err := withRetries(ctx, serviceURL, func() (BaseDto, error) {
+		return throughBreaker(ctx, serviceURL, func() (BaseDto, error) {
+			result, err := sendToUpstream(ctx, method, strings.TrimSuffix(serviceURL, "/")+path, dto)
+			recordUpstreamCall(ctx, serviceURL, method, err)
+			return result, err
+		})
+	})
+	if err != nil {
+		return BaseDto{}, &upstreamError{URL: serviceURL, Err: err}
+	}
+	return result, nil
+}
+
+func sendToUpstream(ctx context.Context, method, targetURL string, dto BaseDto) (BaseDto, error) {
+	if err := injectUpstreamDNSFailure(ctx, targetURL); err != nil {
+		return BaseDto{}, err
+	}
+	payload, err := json.Marshal(dto)
+	if err != nil {
+		return BaseDto{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, bytes.NewReader(payload))
+	if err != nil {
+		return BaseDto{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	body, err := doUpstreamRequest(req)
+	if err != nil {
+		return BaseDto{}, err
+	}
+	var result BaseDto
+	if err := json.Unmarshal(body, &result); err != nil {
+		return BaseDto{}, err
+	}
+	return result, nil
+}
+
+// deleteFromUpstream löscht eine Entität per DELETE %s/api/base/:id bei einem
+// Upstream-Service und meldet, ob sie dort vorhanden war
+func deleteFromUpstream(ctx context.Context, serviceURL, id string) (bool, error) {
+	slog.InfoContext(ctx, "Deleting entity in upstream", "id", id, "upstream", serviceURL)
+	found, err := withRetries(ctx, serviceURL, func() (bool, error) {
+		return throughBreaker(ctx, serviceURL, func() (bool, error) {
+			found, err := removeFromUpstream(ctx, serviceURL, id)
+			recordUpstreamCall(ctx, serviceURL, http.MethodDelete, err)
+			return found, err
+		})
+	})
+	if err != nil {
+		return false, &upstreamError{URL: serviceURL, Err: err}
+	}
+	return found, nil
+}
+
+func removeFromUpstream(ctx context.Context, serviceURL, id string) (bool, error) {
+	if err := injectUpstreamDNSFailure(ctx, serviceURL); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete,
+		strings.TrimSuffix(serviceURL, "/")+"/api/base/"+url.PathEscape(id), nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = doUpstreamRequest(req)
+	var statusErr *upstreamStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// parseUpstreamBackups liest Backup-Upstreams im Format "primary=backup",
+// mehrere Paare werden durch Kommas getrennt
+func parseUpstreamBackups(backupsStr string) map[string]string {
+	backups := map[string]string{}
+	for _, pairStr := range strings.Split(backupsStr, ",") {
+		pairStr = strings.TrimSpace(pairStr)
+		if pairStr == "" {
+			continue
+		}
+		primary, backup, found := strings.Cut(pairStr, "=")
+		if !found || primary == "" || backup == "" {
+			slog.Warn("Ungültiges Backup-Paar wird ignoriert", "pair", pairStr)
+			continue
+		}
+		backups[primary] = backup
+	}
+	return backups
+}
+
+// withFailover führt call gegen den primären Upstream aus und wiederholt ihn
+// nur bei einem Fehler gegen den konfigurierten Backup-Upstream (aktiv/passiv).
+// Der erneute Versuch zählt gegen das Retry-Budget des primären Upstreams.
+func withFailover[T any](ctx context.Context, serviceURL string, call func(serviceURL string) (T, error)) (T, error) {
+	result, err := call(serviceURL)
+	backup, ok := config.UpstreamBackups[serviceURL]
+	if err == nil || !ok || !allowRetry(ctx, serviceURL) {
+		return result, err
+	}
+
+	slog.WarnContext(ctx, "Upstream fehlgeschlagen, weiche auf Backup aus", "upstream", serviceURL, "backup", backup, "error", err)
+	start := time.Now()
+	result, err = call(backup)
+	if err == nil {
+		slog.InfoContext(ctx, "Failover succeeded", "upstream", serviceURL, "backup", backup, "duration", time.Since(start).String())
+	}
+	return result, err
+}
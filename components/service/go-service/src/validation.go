@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultMaxPayloadLength = 1 << 20
+
+// validateBaseDto prüft eine anzulegende Entität: ID und Name dürfen nicht
+// leer sein, die Payload höchstens MaxPayloadLength Zeichen lang.
+func validateBaseDto(dto BaseDto) error {
+	switch {
+	case strings.TrimSpace(dto.ID) == "":
+		return fmt.Errorf("field id must not be empty")
+	case strings.TrimSpace(dto.Name) == "":
+		return fmt.Errorf("field name must not be empty")
+	case len(dto.Payload) > config.MaxPayloadLength:
+		return fmt.Errorf("field payload exceeds the maximum length of %d (got %d)", config.MaxPayloadLength, len(dto.Payload))
+	}
+	return nil
+}
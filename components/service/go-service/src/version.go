@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const serviceVersionHeader = "X-Service-Version"
+
+var serviceInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "microzoo_service_info",
+	Help: "Informationen zur laufenden Service-Instanz, der Wert ist immer 1",
+}, []string{"version"})
+
+// registerVersion veröffentlicht die konfigurierte Version als Metrik-Label,
+// damit sich der Traffic bei Blue/Green- oder Canary-Deployments aufteilen lässt
+func registerVersion() {
+	serviceInfo.WithLabelValues(config.Version).Set(1)
+}
+
+// versionMiddleware setzt die Version als Header in jede Antwort
+func versionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(serviceVersionHeader, config.Version)
+		c.Next()
+	}
+}
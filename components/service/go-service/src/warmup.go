@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+)
+
+// serviceReady wird erst nach dem Aufwärmen auf true gesetzt
+var serviceReady atomic.Bool
+
+// startWarmup wartet StartupDelay ab und ruft danach getAll mehrfach intern
+// über den Router auf, um Caches und Verbindungen vorzuwärmen. Erst danach
+// meldet der Health-Endpunkt UP.
+func startWarmup(handler http.Handler) {
+	if config.WarmupRequests <= 0 {
+		serviceReady.Store(true)
+		return
+	}
+
+	go func() {
+		time.Sleep(config.StartupDelay)
+		slog.Info("Warming up", "requests", config.WarmupRequests)
+
+		start := time.Now()
+		for i := 0; i < config.WarmupRequests; i++ {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/base/", nil))
+			if recorder.Code >= http.StatusBadRequest {
+				slog.Warn("Warmup-Request endete mit Fehlerstatus", "request", i+1, "status", recorder.Code)
+			}
+		}
+
+		serviceReady.Store(true)
+		slog.Info("Warmup finished, service is ready", "duration", time.Since(start).String())
+	}()
+}